@@ -31,8 +31,12 @@ import (
 )
 
 type config struct {
-	Namespace           string `env:"NAMESPACE,              required, report"`
-	SinkConfigStatsAddr string `env:"SINK_CONFIG_STATS_ADDR,           report"`
+	Namespace                 string `env:"NAMESPACE,                    required, report"`
+	SinkConfigStatsAddr       string `env:"SINK_CONFIG_STATS_ADDR,                 report"`
+	HTTPPluginVersion         string `env:"HTTP_PLUGIN_VERSION,                    report"`
+	DefaultEnableTLS          bool   `env:"DEFAULT_ENABLE_TLS,                     report"`
+	DefaultInsecureSkipVerify bool   `env:"DEFAULT_INSECURE_SKIP_VERIFY,           report"`
+	MatchTemplate             string `env:"MATCH_TEMPLATE,                         report"`
 }
 
 func main() {
@@ -41,6 +45,8 @@ func main() {
 
 	conf := config{
 		SinkConfigStatsAddr: ":5000",
+		HTTPPluginVersion:   string(sink.HTTPPluginV1),
+		MatchTemplate:       sink.DefaultMatchTemplate,
 	}
 	err := envstruct.Load(&conf)
 	if err != nil {
@@ -50,6 +56,10 @@ func main() {
 	if err != nil {
 		log.Fatal(err.Error())
 	}
+	httpPluginVersion, err := sink.ParseHTTPPluginVersion(conf.HTTPPluginVersion)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 
 	cfg, err := rest.InClusterConfig()
 	if err != nil {
@@ -81,7 +91,13 @@ func main() {
 		hostOverride,
 	)
 
-	sinkConfig := sink.NewConfig(conf.SinkConfigStatsAddr)
+	sinkConfig := sink.NewConfig(
+		conf.SinkConfigStatsAddr,
+		sink.WithHTTPPluginVersion(httpPluginVersion),
+		sink.WithDefaultEnableTLS(conf.DefaultEnableTLS),
+		sink.WithDefaultInsecureSkipVerify(conf.DefaultInsecureSkipVerify),
+		sink.WithMatchTemplate(conf.MatchTemplate),
+	)
 	controller := sink.NewController(
 		coreV1Client.ConfigMaps(conf.Namespace),
 		coreV1Client.Pods(conf.Namespace),
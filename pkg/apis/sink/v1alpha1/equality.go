@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1
+
+import "reflect"
+
+// SinkSpecEqual reports whether two sink specs render the same config. Nil
+// and empty lists and maps are considered equal.
+func SinkSpecEqual(a, b SinkSpec) bool {
+	return reflect.DeepEqual(normalizeSinkSpec(a), normalizeSinkSpec(b))
+}
+
+func normalizeSinkSpec(s SinkSpec) SinkSpec {
+	if len(s.IncludeNamespaces) == 0 {
+		s.IncludeNamespaces = nil
+	}
+	if len(s.StructuredData) == 0 {
+		s.StructuredData = nil
+	}
+	if len(s.Headers) == 0 {
+		s.Headers = nil
+	}
+	if len(s.Redact) == 0 {
+		s.Redact = nil
+	}
+	if len(s.Matches) == 0 {
+		s.Matches = nil
+	}
+	if len(s.Routes) == 0 {
+		s.Routes = nil
+	}
+	return s
+}
+
+// MetricSinkSpecEqual reports whether two metric sink specs render the same
+// config. Nil and empty lists are considered equal, as are numbers of
+// different types with the same value, such as an int and the float64 it is
+// decoded as from JSON.
+func MetricSinkSpecEqual(a, b MetricSinkSpec) bool {
+	return metricSinkMapsEqual(a.Inputs, b.Inputs) &&
+		metricSinkMapsEqual(a.Outputs, b.Outputs)
+}
+
+func metricSinkMapsEqual(a, b []MetricSinkMap) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for k, av := range a[i] {
+			bv, ok := b[i][k]
+			if !ok || !metricValuesEqual(av, bv) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func metricValuesEqual(a, b interface{}) bool {
+	af, aok := toFloat64(a)
+	bf, bok := toFloat64(b)
+	if aok && bok {
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch tv := v.(type) {
+	case int:
+		return float64(tv), true
+	case int32:
+		return float64(tv), true
+	case int64:
+		return float64(tv), true
+	case float32:
+		return float64(tv), true
+	case float64:
+		return tv, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1_test
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+)
+
+func TestSinkSpecEqual(t *testing.T) {
+	spec := v1alpha1.SinkSpec{
+		Type: "syslog",
+		SyslogSpec: v1alpha1.SyslogSpec{
+			Host: "example.com",
+			Port: 12345,
+		},
+	}
+	a := v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "some-name",
+			Namespace:       "some-namespace",
+			ResourceVersion: "1",
+		},
+		Spec: spec,
+	}
+	b := v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "some-name",
+			Namespace:       "some-namespace",
+			ResourceVersion: "2",
+			Labels:          map[string]string{"some-label": "some-value"},
+		},
+		Spec: spec,
+	}
+	b.Spec.IncludeNamespaces = []string{}
+	b.Spec.Headers = map[string]string{}
+
+	if !v1alpha1.SinkSpecEqual(a.Spec, b.Spec) {
+		t.Error("expected specs differing only in metadata to be equal")
+	}
+
+	b.Spec.Port = 12346
+	if v1alpha1.SinkSpecEqual(a.Spec, b.Spec) {
+		t.Error("expected specs with different ports not to be equal")
+	}
+}
+
+func TestMetricSinkSpecEqual(t *testing.T) {
+	a := v1alpha1.MetricSinkSpec{
+		Inputs: []v1alpha1.MetricSinkMap{
+			{"type": "cpu"},
+		},
+		Outputs: []v1alpha1.MetricSinkMap{
+			{"type": "prometheus_remote_write", "host": "example.com", "port": 9090},
+		},
+	}
+	b := v1alpha1.MetricSinkSpec{
+		Inputs: []v1alpha1.MetricSinkMap{
+			{"type": "cpu"},
+		},
+		Outputs: []v1alpha1.MetricSinkMap{
+			{"type": "prometheus_remote_write", "host": "example.com", "port": float64(9090)},
+		},
+	}
+	if !v1alpha1.MetricSinkSpecEqual(a, b) {
+		t.Error("expected specs with equal numbers of different types to be equal")
+	}
+	if !v1alpha1.MetricSinkSpecEqual(v1alpha1.MetricSinkSpec{}, v1alpha1.MetricSinkSpec{
+		Inputs: []v1alpha1.MetricSinkMap{},
+	}) {
+		t.Error("expected nil and empty inputs to be equal")
+	}
+
+	b.Outputs[0]["port"] = 9091
+	if v1alpha1.MetricSinkSpecEqual(a, b) {
+		t.Error("expected specs with different ports not to be equal")
+	}
+
+	b.Outputs[0]["port"] = 9090
+	b.Inputs = append(b.Inputs, v1alpha1.MetricSinkMap{"type": "mem"})
+	if v1alpha1.MetricSinkSpecEqual(a, b) {
+		t.Error("expected specs with different inputs not to be equal")
+	}
+}
@@ -37,17 +37,272 @@ type SinkSpec struct {
 
 	SyslogSpec  `json:",inline"`
 	WebhookSpec `json:",inline"`
+	GELFSpec    `json:",inline"`
+	OTLPSpec    `json:",inline"`
+	DatadogSpec `json:",inline"`
+	AzureSpec   `json:",inline"`
+
+	// TimeKey is the record key the event timestamp is written to by
+	// outputs that support it. The output default is used when it is
+	// empty.
+	TimeKey string `json:"time_key,omitempty"`
+
+	// DateFormat is the format of the timestamp written to TimeKey, one of
+	// DateFormats, by outputs that support it. The output default is used
+	// when it is empty.
+	DateFormat string `json:"date_format,omitempty"`
+
+	// Disabled stops the sink from being rendered without deleting it.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Workers is the number of threads the output uses to flush records.
+	// The output default is used when it is 0. It may not exceed
+	// MaxWorkers.
+	Workers int `json:"workers,omitempty"`
+
+	// SampleRate forwards roughly one in SampleRate records when greater
+	// than 1.
+	SampleRate int `json:"sample_rate,omitempty"`
+
+	// OnBackpressure is what the sink does when its buffer is full,
+	// either BackpressureDrop or BackpressureBlock. Defaults to
+	// BackpressureDrop. BackpressureBlock pauses the input of the
+	// TailSource, so it requires one.
+	OnBackpressure string `json:"on_backpressure,omitempty"`
+
+	// MinSeverity drops records whose severity key is less severe than
+	// it, one of Severities. Records without a severity are kept.
+	MinSeverity string `json:"min_severity,omitempty"`
+
+	// Redact removes the keys of records matching any of the regular
+	// expressions, e.g. password or token.
+	Redact []string `json:"redact,omitempty"`
+
+	// StripKubernetesMetadata removes the kubernetes key holding the
+	// metadata Fluent Bit enriches records with, to reduce their size.
+	StripKubernetesMetadata bool `json:"strip_kubernetes_metadata,omitempty"`
+
+	// MaxRecordBytes truncates the log key of records to at most
+	// MaxRecordBytes bytes when greater than 0, so downstreams rejecting
+	// large records do not drop the whole batch.
+	MaxRecordBytes int `json:"max_record_bytes,omitempty"`
+
+	// Parser is the name of a parser defined in the parsers file of
+	// Fluent Bit the log key of records is parsed with before they are
+	// sent, e.g. json.
+	Parser string `json:"parser,omitempty"`
+
+	// IncludeNamespaces limits a ClusterLogSink to logs from the listed
+	// namespaces. Logs from every namespace are forwarded when it is empty,
+	// see IncludeSystemNamespaces.
+	IncludeNamespaces []string `json:"include_namespaces,omitempty"`
+
+	// Matches selects the records of a sink by tag with Fluent Bit Match
+	// patterns rather than by namespace, an output being rendered for each.
+	// The patterns of a LogSink must contain its namespace as it appears in
+	// tags, e.g. *_some-namespace_nginx-*, others are left out. The
+	// patterns of a ClusterLogSink take precedence over IncludeNamespaces.
+	Matches []string `json:"matches,omitempty"`
+
+	// CatchAll marks a ClusterLogSink as the default sink. Its output is
+	// rendered last matching every namespace and IncludeNamespaces is
+	// ignored. Fluent Bit routes every record to each output matching it,
+	// so the catch-all sink receives logs whether or not another sink
+	// matched them. Only one ClusterLogSink may be a catch-all. It is
+	// ignored on a LogSink.
+	CatchAll bool `json:"catch_all,omitempty"`
+
+	// Priority orders the outputs of the ClusterLogSinks of a type, those
+	// with a higher Priority being rendered first and ties being ordered
+	// by name. A catch-all sink is rendered last regardless of its
+	// Priority. It is ignored on a LogSink.
+	Priority int `json:"priority,omitempty"`
+
+	// IncludeSystemNamespaces makes a ClusterLogSink without
+	// IncludeNamespaces, or a catch-all one, forward logs from system
+	// namespaces such as kube-system, which are left out by default.
+	IncludeSystemNamespaces bool `json:"include_system_namespaces,omitempty"`
+
+	// RouteByField routes the records of a webhook ClusterLogSink by the
+	// value of a record field, given as a dot separated path such as
+	// kubernetes.namespace_name. Records are re-emitted with the tag
+	// route.<sink name>.<value>, which is sent to the webhook in the
+	// X-Route-Tag header. It is ignored on a LogSink.
+	RouteByField string `json:"route_by_field,omitempty"`
+
+	// Routes send the records of a webhook ClusterLogSink matching a route
+	// to the URL of the first route they match instead of the URL of the
+	// sink, which receives every other record. It is ignored on a LogSink.
+	Routes []RouteSpec `json:"routes,omitempty"`
+
+	// TailSource declares a tail input for a ClusterLogSink whose records are
+	// sent to the sink instead of those matched by IncludeNamespaces or
+	// Matches. It is ignored on a LogSink.
+	TailSource *SourceSpec `json:"tail_source,omitempty"`
+}
+
+// SourceSpec is a tail input reading the files matching Path, whose records
+// are tagged with Tag.
+type SourceSpec struct {
+	// Path is the absolute path of the files read, which may contain
+	// wildcards, e.g. /var/log/app/*.log.
+	Path string `json:"path"`
+	// Tag is the tag of the records read, matched by the outputs of the
+	// sink.
+	Tag string `json:"tag"`
+}
+
+// RouteSpec routes the records whose field matches a pattern to a URL.
+type RouteSpec struct {
+	// Field is the record field matched, given as a dot separated path
+	// such as kubernetes.labels.app.
+	Field string `json:"field"`
+	// Pattern is the regular expression the value of the field is matched
+	// against, e.g. ^(error|fatal)$.
+	Pattern string `json:"pattern"`
+	// URL is the webhook the matching records are sent to.
+	URL string `json:"url"`
 }
 
 type SyslogSpec struct {
-	Host               string `json:"host"`
-	Port               int    `json:"port"`
-	EnableTLS          bool   `json:"enable_tls"`
-	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// EnableTLS is also honored by webhook sinks, where it enables TLS
+	// regardless of the URL scheme.
+	EnableTLS bool `json:"enable_tls"`
+	// InsecureSkipVerify disables verification of the server certificate.
+	// When it is set it takes precedence over the sink controller default
+	// applied to sinks that do not set EnableTLS.
+	InsecureSkipVerify *bool `json:"insecure_skip_verify,omitempty"`
+	// DisableTLS opts a syslog sink out of TLS when the sink controller
+	// enables it by default. It has no effect when EnableTLS is set.
+	DisableTLS bool `json:"disable_tls,omitempty"`
+	// CASecretRef refers to the PEM encoded CA used to verify the server
+	// certificate when TLS is enabled. The secret must be resolved by the
+	// sink controller before the sink is rendered.
+	CASecretRef *SecretRef `json:"ca_secret_ref,omitempty"`
+	// CAFile, CertFile and KeyFile are paths to PEM encoded files mounted
+	// into Fluent Bit, which require EnableTLS. CAFile verifies the server
+	// certificate in place of CASecretRef, CertFile and KeyFile
+	// authenticate the client. Webhook sinks take paths with CA,
+	// ClientCert and ClientKey instead.
+	CAFile   string `json:"ca_file,omitempty"`
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	// ReconnectBackoffMs and ReconnectMaxMs configure the initial and
+	// maximum delay of the jittered exponential backoff used when a syslog
+	// sink reconnects. The plugin default is used when they are not set.
+	ReconnectBackoffMs int `json:"reconnect_backoff_ms,omitempty"`
+	ReconnectMaxMs     int `json:"reconnect_max_ms,omitempty"`
+	// StructuredData maps RFC 5424 structured data element names to the
+	// record accessor expressions, e.g. $kubernetes['pod_name'], their
+	// values are read from by the syslog plugin.
+	StructuredData map[string]string `json:"structured_data,omitempty"`
+	// Transport is tcp, the default, or udp. TLS is not supported over
+	// udp.
+	Transport string `json:"transport,omitempty"`
+	// SyslogFormat selects the framing used by the syslog plugin, rfc5424,
+	// the default, or rfc3164.
+	SyslogFormat string `json:"syslog_format,omitempty"`
+	// MessageKey is the record key the syslog plugin sends as the message,
+	// e.g. log. The whole record is sent as JSON when it is not set.
+	MessageKey string `json:"message_key,omitempty"`
+}
+
+// SecretRef refers to a key of a Secret. The Secret is in the namespace of
+// a LogSink, or the namespace of the sink controller for a ClusterLogSink.
+type SecretRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
 }
 
 type WebhookSpec struct {
 	URL string `json:"url"`
+
+	// CA, ClientCert and ClientKey are paths to PEM encoded files used
+	// when TLS is enabled. CA verifies the server certificate, ClientCert
+	// and ClientKey authenticate the client.
+	CA         string `json:"ca,omitempty"`
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+	// TLSVerify enables or disables verification of the server
+	// certificate. The output default is used when it is unset.
+	TLSVerify *bool `json:"tls_verify,omitempty"`
+	// TLSVHost is the hostname sent for SNI when TLS is enabled, for
+	// when the URL names an IP address.
+	TLSVHost string `json:"tls_vhost,omitempty"`
+	// InfiniteRetries retries failed flushes without limit, taking
+	// precedence over any retry limit. It is honored by every output
+	// except syslog.
+	InfiniteRetries bool `json:"infinite_retries,omitempty"`
+	// ChunkSize is the size of the chunks the input of the TailSource
+	// reads, so it requires one, and BufferSize is the total size of the
+	// chunks an output buffers, e.g. 512K or 64M. The defaults are used
+	// when they are empty. Like InfiniteRetries BufferSize is honored by
+	// every output except syslog.
+	ChunkSize  string `json:"chunk_size,omitempty"`
+	BufferSize string `json:"buffer_size,omitempty"`
+	// KeepAlive enables or disables reusing connections to the webhook,
+	// e.g. disabled behind a proxy closing idle connections. The output
+	// default is used when it is unset.
+	KeepAlive *bool `json:"keep_alive,omitempty"`
+	// ConnectTimeoutSeconds is how long connecting to the webhook may
+	// take. The output default is used when it is 0.
+	ConnectTimeoutSeconds int `json:"connect_timeout_seconds,omitempty"`
+	// HTTP2 sends records to the webhook over HTTP/2 rather than
+	// HTTP/1.1.
+	HTTP2 bool `json:"http2,omitempty"`
+}
+
+const (
+	// BackpressureDrop discards records when the output buffer is full.
+	BackpressureDrop = "drop"
+	// BackpressureBlock pauses the TailSource input until the buffer has
+	// room so no records are discarded.
+	BackpressureBlock = "block"
+)
+
+// GELFSpec configures a gelf sink. The sink is sent to the Host and Port of
+// the SyslogSpec, and when Mode is tls, InsecureSkipVerify disables
+// certificate verification.
+type GELFSpec struct {
+	// Mode is the transport, one of udp, tcp or tls. Defaults to udp.
+	Mode string `json:"mode,omitempty"`
+}
+
+// OTLPSpec configures an otlp sink. The sink is sent to the Host and Port of
+// the SyslogSpec, with TLS enabled by EnableTLS.
+type OTLPSpec struct {
+	// URI is the path logs are sent to. Defaults to /v1/logs.
+	URI string `json:"uri,omitempty"`
+	// Headers are added to every request, e.g. for authorization.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// DatadogSpec configures a datadog sink.
+type DatadogSpec struct {
+	// APIKey authenticates with Datadog. It is rendered into the config
+	// and must not be logged.
+	APIKey string `json:"api_key,omitempty"`
+	// Site is the Datadog site logs are sent to. Defaults to
+	// datadoghq.com.
+	Site string `json:"site,omitempty"`
+	// Service and Source are attached to every record as the service and
+	// source Datadog uses to group them.
+	Service string `json:"service,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// AzureSpec configures an azure sink sending to Azure Log Analytics.
+type AzureSpec struct {
+	// CustomerID is the ID of the Log Analytics workspace.
+	CustomerID string `json:"customer_id,omitempty"`
+	// SharedKey authenticates with the workspace. It is rendered into the
+	// config and must not be logged.
+	SharedKey string `json:"shared_key,omitempty"`
+	// LogType is the name of the record type logs are stored as. The
+	// output default is used when it is empty.
+	LogType string `json:"log_type,omitempty"`
 }
 
 // SinkStatus is the status for a Sink resource
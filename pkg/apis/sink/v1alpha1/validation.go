@@ -0,0 +1,532 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// FieldError is returned by the Validate methods for an invalid field. It
+// wraps the error describing the problem, e.g. ErrEmptyHost, so it can be
+// matched with errors.Is.
+type FieldError struct {
+	// Field is the JSON path of the field relative to the spec, e.g. port
+	// or tail_source.
+	Field  string
+	Detail string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return e.Detail
+}
+
+// Unwrap returns the error describing the problem.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError returns a FieldError for field wrapping err, or nil if err is
+// nil.
+func fieldError(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &FieldError{Field: field, Detail: err.Error(), Err: err}
+}
+
+// MaxWorkers is the largest number of output workers a sink may request.
+const MaxWorkers = 16
+
+// ErrInvalidWorkers is returned when a spec requests a negative number of
+// workers or more than MaxWorkers.
+var ErrInvalidWorkers = fmt.Errorf("workers must be between 0 and %d", MaxWorkers)
+
+// ErrInvalidSampleRate is returned when a spec has a negative sample rate.
+var ErrInvalidSampleRate = errors.New("sample_rate must not be negative")
+
+// ErrInvalidMaxRecordBytes is returned when a spec has a negative maximum
+// record size.
+var ErrInvalidMaxRecordBytes = errors.New("max_record_bytes must not be negative")
+
+// ErrInvalidBackpressure is returned when OnBackpressure is not one of
+// BackpressureDrop or BackpressureBlock.
+var ErrInvalidBackpressure = fmt.Errorf("on_backpressure must be %s or %s", BackpressureDrop, BackpressureBlock)
+
+// ErrBlockWithoutSource is returned when a spec blocks on backpressure
+// without a TailSource, having no input of its own to pause.
+var ErrBlockWithoutSource = fmt.Errorf("on_backpressure %s requires a tail_source", BackpressureBlock)
+
+// DateFormats are the timestamp formats supported by DateFormat.
+var DateFormats = []string{"iso8601", "epoch", "java_sql_timestamp"}
+
+// ErrInvalidDateFormat is returned when DateFormat is not one of DateFormats.
+var ErrInvalidDateFormat = fmt.Errorf("date_format must be one of %v", DateFormats)
+
+// ErrInvalidRouteByField is returned when RouteByField is not a dot
+// separated path of field names, or is set on a sink that is not a webhook.
+var ErrInvalidRouteByField = errors.New("route_by_field must be a dot separated field path on a webhook sink")
+
+var fieldPathPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// ErrInvalidRoutes is returned when a route has no URL, its field is not a
+// dot separated path of field names or its pattern is not a regular
+// expression without whitespace, or when routes are set on a sink that is
+// not a webhook or has a RouteByField.
+var ErrInvalidRoutes = errors.New("routes must each have a field path, a pattern and a url, on a webhook sink without route_by_field")
+
+// ErrEmptyHost is returned when a syslog sink has no host. Such a sink is
+// not rendered since Fluent Bit accepts the address but drops every record.
+var ErrEmptyHost = errors.New("host must not be empty")
+
+// ErrInvalidPort is returned when a spec sending to a host has a port out of
+// range.
+var ErrInvalidPort = errors.New("port must be between 1 and 65535")
+
+// ErrInvalidURL is returned when a webhook sink has a URL that cannot be
+// parsed or has no host.
+var ErrInvalidURL = errors.New("url must be an absolute URL with a host")
+
+// ErrInsecureSkipVerifyWithoutTLS is returned when a spec disables
+// certificate verification without enabling TLS. No TLS configuration is
+// rendered in that case so the setting would have no effect.
+var ErrInsecureSkipVerifyWithoutTLS = errors.New("insecure_skip_verify requires enable_tls")
+
+// ErrTLSFileWithoutTLS is returned when a syslog sink sets ca_file,
+// cert_file or key_file without enabling TLS, in which case they would be
+// ignored.
+var ErrTLSFileWithoutTLS = errors.New("ca_file, cert_file and key_file require enable_tls")
+
+// ErrCAFileWithSecretRef is returned when a syslog sink sets both the inline
+// and the file form of its CA.
+var ErrCAFileWithSecretRef = errors.New("ca_file and ca_secret_ref are mutually exclusive")
+
+// ErrIncompleteClientCert is returned when a syslog sink sets only one of
+// cert_file and key_file.
+var ErrIncompleteClientCert = errors.New("cert_file and key_file must be set together")
+
+// SyslogTransports are the transports supported by syslog sinks.
+var SyslogTransports = []string{"tcp", "udp"}
+
+// ErrInvalidSyslogTransport is returned when a syslog sink has an
+// unsupported transport.
+var ErrInvalidSyslogTransport = fmt.Errorf("transport must be one of %v", SyslogTransports)
+
+// ErrTLSOverUDP is returned when a syslog sink enables TLS over udp.
+var ErrTLSOverUDP = errors.New("enable_tls is not supported with transport udp")
+
+// SyslogFormats are the framings supported by syslog sinks.
+var SyslogFormats = []string{"rfc5424", "rfc3164"}
+
+// ErrInvalidSyslogFormat is returned when a syslog sink has an unsupported
+// format.
+var ErrInvalidSyslogFormat = fmt.Errorf("syslog_format must be one of %v", SyslogFormats)
+
+// GELFModes are the transports supported by gelf sinks.
+var GELFModes = []string{"udp", "tcp", "tls"}
+
+// ErrInvalidGELFMode is returned when a gelf sink has an unsupported mode.
+var ErrInvalidGELFMode = fmt.Errorf("mode must be one of %v", GELFModes)
+
+// Severities are the syslog severities accepted by MinSeverity, from most to
+// least severe.
+var Severities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// ErrInvalidSeverity is returned when a severity is not one of Severities.
+var ErrInvalidSeverity = fmt.Errorf("severity must be one of %v", Severities)
+
+// ValidateSeverity checks that severity is one of Severities. An empty
+// severity is valid and disables filtering.
+func ValidateSeverity(severity string) error {
+	if severity == "" {
+		return nil
+	}
+	for _, s := range Severities {
+		if severity == s {
+			return nil
+		}
+	}
+	return ErrInvalidSeverity
+}
+
+// ErrInvalidRedact is returned when a Redact pattern is not a valid regular
+// expression.
+var ErrInvalidRedact = errors.New("redact must be a list of regular expressions")
+
+// ValidateRedact checks that every pattern is a valid regular expression.
+func ValidateRedact(patterns []string) error {
+	for _, p := range patterns {
+		if p == "" {
+			return ErrInvalidRedact
+		}
+		if _, err := regexp.Compile(p); err != nil {
+			return ErrInvalidRedact
+		}
+	}
+	return nil
+}
+
+// ErrInvalidParser is returned when Parser is set to a name that is blank or
+// contains whitespace.
+var ErrInvalidParser = errors.New("parser must be a name without whitespace")
+
+// ValidateParser checks that the parser name contains no whitespace. An
+// empty name is valid and parses nothing.
+func ValidateParser(name string) error {
+	if strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return ErrInvalidParser
+	}
+	return nil
+}
+
+// ErrInvalidSize is returned when ChunkSize or BufferSize is not a number of
+// bytes optionally followed by a K, M or G unit, e.g. 512K or 64MB.
+var ErrInvalidSize = errors.New("chunk_size and buffer_size must be a size such as 512K or 64M")
+
+// ErrChunkSizeWithoutSource is returned when a spec sets ChunkSize without
+// a TailSource, having no input of its own to read in chunks of that size.
+var ErrChunkSizeWithoutSource = errors.New("chunk_size requires a tail_source")
+
+var sizePattern = regexp.MustCompile(`^[0-9]+([KkMmGg][Bb]?)?$`)
+
+// ValidateSize checks that size is a number of bytes optionally followed by
+// a unit. An empty size is valid and uses the output default.
+func ValidateSize(size string) error {
+	if size != "" && !sizePattern.MatchString(size) {
+		return ErrInvalidSize
+	}
+	return nil
+}
+
+// ErrInvalidMatches is returned when a Matches pattern is empty or contains
+// whitespace.
+var ErrInvalidMatches = errors.New("matches must be a list of patterns without whitespace")
+
+// ValidateMatches checks that every pattern is non-empty and contains no
+// whitespace, which would end the Match directive.
+func ValidateMatches(patterns []string) error {
+	for _, p := range patterns {
+		if p == "" || strings.IndexFunc(p, unicode.IsSpace) >= 0 {
+			return ErrInvalidMatches
+		}
+	}
+	return nil
+}
+
+// ValidateDateFormat checks that format is one of DateFormats. An empty
+// format is valid and uses the output default.
+func ValidateDateFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range DateFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return ErrInvalidDateFormat
+}
+
+// ValidateRouteByField checks that field is a dot separated path of field
+// names on a webhook sink. An empty field is valid and disables routing.
+func ValidateRouteByField(sinkType, field string) error {
+	if field == "" {
+		return nil
+	}
+	if sinkType != "webhook" || !fieldPathPattern.MatchString(field) {
+		return ErrInvalidRouteByField
+	}
+	return nil
+}
+
+// ValidateRoutes checks that every route has a URL, a dot separated field
+// path and a regular expression pattern without whitespace, and that routes
+// are only set on a webhook sink without a RouteByField.
+func ValidateRoutes(sinkType, routeByField string, routes []RouteSpec) error {
+	if len(routes) == 0 {
+		return nil
+	}
+	if sinkType != "webhook" || routeByField != "" {
+		return ErrInvalidRoutes
+	}
+	for _, r := range routes {
+		if r.URL == "" || !fieldPathPattern.MatchString(r.Field) {
+			return ErrInvalidRoutes
+		}
+		if r.Pattern == "" || strings.IndexFunc(r.Pattern, unicode.IsSpace) >= 0 {
+			return ErrInvalidRoutes
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return ErrInvalidRoutes
+		}
+	}
+	return nil
+}
+
+// ErrInvalidSource is returned when a TailSource does not have an absolute
+// path and a tag, or either contains whitespace.
+var ErrInvalidSource = errors.New("tail_source must have an absolute path and a tag without whitespace")
+
+// ValidateSource checks that the source has an absolute path and a tag
+// without whitespace. A nil source is valid.
+func ValidateSource(source *SourceSpec) error {
+	if source == nil {
+		return nil
+	}
+	if !strings.HasPrefix(source.Path, "/") || source.Tag == "" {
+		return ErrInvalidSource
+	}
+	if strings.IndexFunc(source.Path+source.Tag, unicode.IsSpace) >= 0 {
+		return ErrInvalidSource
+	}
+	return nil
+}
+
+// ErrFieldNotForType is returned when a spec sets a field of another sink
+// type, which would be ignored, e.g. url on a syslog sink.
+type ErrFieldNotForType struct {
+	Field string
+	Type  string
+}
+
+func (e *ErrFieldNotForType) Error() string {
+	return fmt.Sprintf("%s is not supported by %s sinks", e.Field, e.Type)
+}
+
+// typeFields are the fields only supported by some sink types, in the order
+// they are checked.
+var typeFields = []struct {
+	field string
+	types []string
+	set   func(SinkSpec) bool
+}{
+	{"host", []string{"syslog", "gelf", "otlp"}, func(s SinkSpec) bool { return s.Host != "" }},
+	{"port", []string{"syslog", "gelf", "otlp"}, func(s SinkSpec) bool { return s.Port != 0 }},
+	{"disable_tls", []string{"syslog"}, func(s SinkSpec) bool { return s.DisableTLS }},
+	{"ca_secret_ref", []string{"syslog"}, func(s SinkSpec) bool { return s.CASecretRef != nil }},
+	{"ca_file", []string{"syslog"}, func(s SinkSpec) bool { return s.CAFile != "" }},
+	{"cert_file", []string{"syslog"}, func(s SinkSpec) bool { return s.CertFile != "" }},
+	{"key_file", []string{"syslog"}, func(s SinkSpec) bool { return s.KeyFile != "" }},
+	{"reconnect_backoff_ms", []string{"syslog"}, func(s SinkSpec) bool { return s.ReconnectBackoffMs != 0 }},
+	{"reconnect_max_ms", []string{"syslog"}, func(s SinkSpec) bool { return s.ReconnectMaxMs != 0 }},
+	{"structured_data", []string{"syslog"}, func(s SinkSpec) bool { return len(s.StructuredData) > 0 }},
+	{"transport", []string{"syslog"}, func(s SinkSpec) bool { return s.Transport != "" }},
+	{"syslog_format", []string{"syslog"}, func(s SinkSpec) bool { return s.SyslogFormat != "" }},
+	{"message_key", []string{"syslog"}, func(s SinkSpec) bool { return s.MessageKey != "" }},
+	{"url", []string{"webhook"}, func(s SinkSpec) bool { return s.URL != "" }},
+	{"ca", []string{"webhook"}, func(s SinkSpec) bool { return s.CA != "" }},
+	{"client_cert", []string{"webhook"}, func(s SinkSpec) bool { return s.ClientCert != "" }},
+	{"client_key", []string{"webhook"}, func(s SinkSpec) bool { return s.ClientKey != "" }},
+	{"tls_verify", []string{"webhook"}, func(s SinkSpec) bool { return s.TLSVerify != nil }},
+	{"tls_vhost", []string{"webhook"}, func(s SinkSpec) bool { return s.TLSVHost != "" }},
+	{"keep_alive", []string{"webhook"}, func(s SinkSpec) bool { return s.KeepAlive != nil }},
+	{"connect_timeout_seconds", []string{"webhook"}, func(s SinkSpec) bool { return s.ConnectTimeoutSeconds != 0 }},
+	{"http2", []string{"webhook"}, func(s SinkSpec) bool { return s.HTTP2 }},
+	{"mode", []string{"gelf"}, func(s SinkSpec) bool { return s.Mode != "" }},
+	{"uri", []string{"otlp"}, func(s SinkSpec) bool { return s.URI != "" }},
+	{"headers", []string{"otlp"}, func(s SinkSpec) bool { return len(s.Headers) > 0 }},
+	{"api_key", []string{"datadog"}, func(s SinkSpec) bool { return s.APIKey != "" }},
+	{"site", []string{"datadog"}, func(s SinkSpec) bool { return s.Site != "" }},
+	{"service", []string{"datadog"}, func(s SinkSpec) bool { return s.Service != "" }},
+	{"source", []string{"datadog"}, func(s SinkSpec) bool { return s.Source != "" }},
+	{"customer_id", []string{"azure"}, func(s SinkSpec) bool { return s.CustomerID != "" }},
+	{"shared_key", []string{"azure"}, func(s SinkSpec) bool { return s.SharedKey != "" }},
+	{"log_type", []string{"azure"}, func(s SinkSpec) bool { return s.LogType != "" }},
+}
+
+// ValidateFieldsForType checks that the spec only sets the fields of its
+// type among those specific to some types, returning an ErrFieldNotForType
+// for the first other field set. Types without type specific fields, such as
+// those of custom renderers, are not checked.
+func ValidateFieldsForType(s SinkSpec) error {
+	known := false
+	for _, f := range typeFields {
+		if oneOf(s.Type, f.types) {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil
+	}
+	for _, f := range typeFields {
+		if !oneOf(s.Type, f.types) && f.set(s) {
+			return &ErrFieldNotForType{Field: f.field, Type: s.Type}
+		}
+	}
+	return nil
+}
+
+// Validate checks the settings shared by every sink type, followed by the
+// settings of the spec of its type. A FieldError is returned for the first
+// invalid field.
+func (s SinkSpec) Validate() error {
+	if s.Workers < 0 || s.Workers > MaxWorkers {
+		return fieldError("workers", ErrInvalidWorkers)
+	}
+	if s.SampleRate < 0 {
+		return fieldError("sample_rate", ErrInvalidSampleRate)
+	}
+	if s.MaxRecordBytes < 0 {
+		return fieldError("max_record_bytes", ErrInvalidMaxRecordBytes)
+	}
+	switch s.OnBackpressure {
+	case "", BackpressureDrop:
+	case BackpressureBlock:
+		if s.TailSource == nil {
+			return fieldError("on_backpressure", ErrBlockWithoutSource)
+		}
+	default:
+		return fieldError("on_backpressure", ErrInvalidBackpressure)
+	}
+	if err := ValidateSeverity(s.MinSeverity); err != nil {
+		return fieldError("min_severity", err)
+	}
+	if err := ValidateDateFormat(s.DateFormat); err != nil {
+		return fieldError("date_format", err)
+	}
+	if err := ValidateRedact(s.Redact); err != nil {
+		return fieldError("redact", err)
+	}
+	if err := ValidateMatches(s.Matches); err != nil {
+		return fieldError("matches", err)
+	}
+	if err := ValidateParser(s.Parser); err != nil {
+		return fieldError("parser", err)
+	}
+	if err := ValidateSize(s.ChunkSize); err != nil {
+		return fieldError("chunk_size", err)
+	}
+	if s.ChunkSize != "" && s.TailSource == nil {
+		return fieldError("chunk_size", ErrChunkSizeWithoutSource)
+	}
+	if err := ValidateSize(s.BufferSize); err != nil {
+		return fieldError("buffer_size", err)
+	}
+	if err := ValidateRouteByField(s.Type, s.RouteByField); err != nil {
+		return fieldError("route_by_field", err)
+	}
+	if err := ValidateRoutes(s.Type, s.RouteByField, s.Routes); err != nil {
+		return fieldError("routes", err)
+	}
+	if err := ValidateSource(s.TailSource); err != nil {
+		return fieldError("tail_source", err)
+	}
+	if err := ValidateFieldsForType(s); err != nil {
+		return fieldError(err.(*ErrFieldNotForType).Field, err)
+	}
+
+	switch s.Type {
+	case "syslog":
+		return s.SyslogSpec.Validate()
+	case "webhook":
+		if s.URL == "" {
+			return nil
+		}
+		if u, err := url.Parse(s.URL); err != nil || u.Host == "" {
+			return fieldError("url", ErrInvalidURL)
+		}
+	case "gelf":
+		if err := s.GELFSpec.Validate(); err != nil {
+			return err
+		}
+		return validatePort(s.Port)
+	case "otlp":
+		return validatePort(s.Port)
+	}
+	return nil
+}
+
+// Validate checks the spec for settings that conflict with each other, that
+// the host and port are set and that the transport and format are
+// supported. A FieldError is returned for the first invalid field.
+func (s SyslogSpec) Validate() error {
+	if s.InsecureSkipVerify != nil && *s.InsecureSkipVerify && !s.EnableTLS {
+		return fieldError("insecure_skip_verify", ErrInsecureSkipVerifyWithoutTLS)
+	}
+	if s.Host == "" {
+		return fieldError("host", ErrEmptyHost)
+	}
+	if err := validatePort(s.Port); err != nil {
+		return err
+	}
+	if s.Transport != "" && !oneOf(s.Transport, SyslogTransports) {
+		return fieldError("transport", ErrInvalidSyslogTransport)
+	}
+	if s.Transport == "udp" && s.EnableTLS {
+		return fieldError("transport", ErrTLSOverUDP)
+	}
+	if s.SyslogFormat != "" && !oneOf(s.SyslogFormat, SyslogFormats) {
+		return fieldError("syslog_format", ErrInvalidSyslogFormat)
+	}
+	if !s.EnableTLS {
+		switch {
+		case s.CAFile != "":
+			return fieldError("ca_file", ErrTLSFileWithoutTLS)
+		case s.CertFile != "":
+			return fieldError("cert_file", ErrTLSFileWithoutTLS)
+		case s.KeyFile != "":
+			return fieldError("key_file", ErrTLSFileWithoutTLS)
+		}
+	}
+	if s.CAFile != "" && s.CASecretRef != nil {
+		return fieldError("ca_file", ErrCAFileWithSecretRef)
+	}
+	if s.CertFile == "" && s.KeyFile != "" {
+		return fieldError("cert_file", ErrIncompleteClientCert)
+	}
+	if s.CertFile != "" && s.KeyFile == "" {
+		return fieldError("key_file", ErrIncompleteClientCert)
+	}
+	return nil
+}
+
+// validatePort returns a FieldError if port is out of range.
+func validatePort(port int) error {
+	if port < 1 || port > 65535 {
+		return fieldError("port", ErrInvalidPort)
+	}
+	return nil
+}
+
+// oneOf reports whether v is one of values.
+func oneOf(v string, values []string) bool {
+	for _, value := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the mode is supported, returning a FieldError
+// otherwise. An empty mode is valid and defaults to udp.
+func (s GELFSpec) Validate() error {
+	if s.Mode == "" {
+		return nil
+	}
+	for _, m := range GELFModes {
+		if s.Mode == m {
+			return nil
+		}
+	}
+	return fieldError("mode", ErrInvalidGELFMode)
+}
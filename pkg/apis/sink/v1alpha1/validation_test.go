@@ -0,0 +1,705 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1alpha1_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+)
+
+func TestValidateFieldsForType(t *testing.T) {
+	verify := false
+	testCases := map[string]struct {
+		spec          v1alpha1.SinkSpec
+		expectedField string
+	}{
+		"syslog": {
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:       "example.com",
+					Port:       514,
+					MessageKey: "log",
+				},
+			},
+		},
+		"webhook with tls settings": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					EnableTLS: true,
+				},
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL:       "https://example.com",
+					TLSVerify: &verify,
+				},
+			},
+		},
+		"custom type": {
+			spec: v1alpha1.SinkSpec{
+				Type: "custom",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com",
+				},
+			},
+		},
+		"syslog with url": {
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 514,
+				},
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com",
+				},
+			},
+			expectedField: "url",
+		},
+		"webhook with host": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+				},
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com",
+				},
+			},
+			expectedField: "host",
+		},
+		"gelf with structured data": {
+			spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:           "example.com",
+					Port:           12201,
+					StructuredData: map[string]string{"pod@32473": "$kubernetes['pod_name']"},
+				},
+			},
+			expectedField: "structured_data",
+		},
+		"syslog with gelf mode": {
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 514,
+				},
+				GELFSpec: v1alpha1.GELFSpec{
+					Mode: "tcp",
+				},
+			},
+			expectedField: "mode",
+		},
+		"datadog with azure key": {
+			spec: v1alpha1.SinkSpec{
+				Type: "datadog",
+				DatadogSpec: v1alpha1.DatadogSpec{
+					APIKey: "some-key",
+				},
+				AzureSpec: v1alpha1.AzureSpec{
+					SharedKey: "some-shared-key",
+				},
+			},
+			expectedField: "shared_key",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := v1alpha1.ValidateFieldsForType(tc.spec)
+			if tc.expectedField == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			fieldErr, ok := err.(*v1alpha1.ErrFieldNotForType)
+			if !ok {
+				t.Fatalf("expected an ErrFieldNotForType, got %v", err)
+			}
+			if fieldErr.Field != tc.expectedField || fieldErr.Type != tc.spec.Type {
+				t.Errorf("expected field %s of %s, got %+v", tc.expectedField, tc.spec.Type, fieldErr)
+			}
+			if tc.spec.Validate() == nil {
+				t.Error("expected Validate to report the field")
+			}
+		})
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	testCases := map[string]struct {
+		source      *v1alpha1.SourceSpec
+		expectedErr error
+	}{
+		"unset": {},
+		"valid": {
+			source: &v1alpha1.SourceSpec{Path: "/var/log/app/*.log", Tag: "app.logs"},
+		},
+		"relative path": {
+			source:      &v1alpha1.SourceSpec{Path: "app/*.log", Tag: "app.logs"},
+			expectedErr: v1alpha1.ErrInvalidSource,
+		},
+		"no tag": {
+			source:      &v1alpha1.SourceSpec{Path: "/var/log/app/*.log"},
+			expectedErr: v1alpha1.ErrInvalidSource,
+		},
+		"whitespace in path": {
+			source:      &v1alpha1.SourceSpec{Path: "/var/log/my app.log", Tag: "app.logs"},
+			expectedErr: v1alpha1.ErrInvalidSource,
+		},
+		"whitespace in tag": {
+			source:      &v1alpha1.SourceSpec{Path: "/var/log/app/*.log", Tag: "app logs"},
+			expectedErr: v1alpha1.ErrInvalidSource,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := v1alpha1.ValidateSource(tc.source)
+			if err != tc.expectedErr {
+				t.Errorf("ValidateSource error not equal: Expected: %v Actual: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestSyslogSpecValidate(t *testing.T) {
+	insecure, verify := true, false
+	testCases := map[string]struct {
+		spec        v1alpha1.SyslogSpec
+		expectedErr error
+	}{
+		"no tls": {
+			spec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+		"tls": {
+			spec: v1alpha1.SyslogSpec{
+				Host:      "example.com",
+				Port:      12345,
+				EnableTLS: true,
+			},
+		},
+		"tls with insecure skip verify": {
+			spec: v1alpha1.SyslogSpec{
+				Host:               "example.com",
+				Port:               12345,
+				EnableTLS:          true,
+				InsecureSkipVerify: &insecure,
+			},
+		},
+		"empty host": {
+			spec: v1alpha1.SyslogSpec{
+				Port: 514,
+			},
+			expectedErr: v1alpha1.ErrEmptyHost,
+		},
+		"insecure skip verify without tls": {
+			spec: v1alpha1.SyslogSpec{
+				Host:               "example.com",
+				Port:               12345,
+				InsecureSkipVerify: &insecure,
+			},
+			expectedErr: v1alpha1.ErrInsecureSkipVerifyWithoutTLS,
+		},
+		"verification without tls": {
+			spec: v1alpha1.SyslogSpec{
+				Host:               "example.com",
+				Port:               12345,
+				InsecureSkipVerify: &verify,
+			},
+		},
+		"rfc3164 over udp": {
+			spec: v1alpha1.SyslogSpec{
+				Host:         "example.com",
+				Port:         514,
+				Transport:    "udp",
+				SyslogFormat: "rfc3164",
+			},
+		},
+		"rfc5424 over tcp": {
+			spec: v1alpha1.SyslogSpec{
+				Host:         "example.com",
+				Port:         514,
+				Transport:    "tcp",
+				SyslogFormat: "rfc5424",
+			},
+		},
+		"invalid transport": {
+			spec: v1alpha1.SyslogSpec{
+				Host:      "example.com",
+				Port:      514,
+				Transport: "tls",
+			},
+			expectedErr: v1alpha1.ErrInvalidSyslogTransport,
+		},
+		"tls over udp": {
+			spec: v1alpha1.SyslogSpec{
+				Host:      "example.com",
+				Port:      514,
+				EnableTLS: true,
+				Transport: "udp",
+			},
+			expectedErr: v1alpha1.ErrTLSOverUDP,
+		},
+		"invalid format": {
+			spec: v1alpha1.SyslogSpec{
+				Host:         "example.com",
+				Port:         514,
+				SyslogFormat: "rfc822",
+			},
+			expectedErr: v1alpha1.ErrInvalidSyslogFormat,
+		},
+		"tls files": {
+			spec: v1alpha1.SyslogSpec{
+				Host:      "example.com",
+				Port:      514,
+				EnableTLS: true,
+				CAFile:    "/etc/tls/ca.crt",
+				CertFile:  "/etc/tls/tls.crt",
+				KeyFile:   "/etc/tls/tls.key",
+			},
+		},
+		"ca file without tls": {
+			spec: v1alpha1.SyslogSpec{
+				Host:   "example.com",
+				Port:   514,
+				CAFile: "/etc/tls/ca.crt",
+			},
+			expectedErr: v1alpha1.ErrTLSFileWithoutTLS,
+		},
+		"client cert without tls": {
+			spec: v1alpha1.SyslogSpec{
+				Host:     "example.com",
+				Port:     514,
+				CertFile: "/etc/tls/tls.crt",
+				KeyFile:  "/etc/tls/tls.key",
+			},
+			expectedErr: v1alpha1.ErrTLSFileWithoutTLS,
+		},
+		"ca file and secret ref": {
+			spec: v1alpha1.SyslogSpec{
+				Host:        "example.com",
+				Port:        514,
+				EnableTLS:   true,
+				CAFile:      "/etc/tls/ca.crt",
+				CASecretRef: &v1alpha1.SecretRef{Name: "some-secret", Key: "ca.crt"},
+			},
+			expectedErr: v1alpha1.ErrCAFileWithSecretRef,
+		},
+		"cert file without key file": {
+			spec: v1alpha1.SyslogSpec{
+				Host:      "example.com",
+				Port:      514,
+				EnableTLS: true,
+				CertFile:  "/etc/tls/tls.crt",
+			},
+			expectedErr: v1alpha1.ErrIncompleteClientCert,
+		},
+		"key file without cert file": {
+			spec: v1alpha1.SyslogSpec{
+				Host:      "example.com",
+				Port:      514,
+				EnableTLS: true,
+				KeyFile:   "/etc/tls/tls.key",
+			},
+			expectedErr: v1alpha1.ErrIncompleteClientCert,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.spec.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Validate error not equal: Expected: %v Actual: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestSinkSpecValidate(t *testing.T) {
+	insecure := true
+	testCases := map[string]struct {
+		spec        v1alpha1.SinkSpec
+		expectedErr error
+	}{
+		"valid": {
+			spec: v1alpha1.SinkSpec{
+				Type:           "webhook",
+				Workers:        2,
+				SampleRate:     10,
+				OnBackpressure: v1alpha1.BackpressureBlock,
+				MinSeverity:    "warning",
+				DateFormat:     "iso8601",
+				TailSource: &v1alpha1.SourceSpec{
+					Path: "/var/log/audit.log",
+					Tag:  "audit",
+				},
+			},
+		},
+		"too many workers": {
+			spec: v1alpha1.SinkSpec{
+				Type:    "webhook",
+				Workers: v1alpha1.MaxWorkers + 1,
+			},
+			expectedErr: v1alpha1.ErrInvalidWorkers,
+		},
+		"negative sample rate": {
+			spec: v1alpha1.SinkSpec{
+				Type:       "webhook",
+				SampleRate: -1,
+			},
+			expectedErr: v1alpha1.ErrInvalidSampleRate,
+		},
+		"negative max record bytes": {
+			spec: v1alpha1.SinkSpec{
+				Type:           "webhook",
+				MaxRecordBytes: -1,
+			},
+			expectedErr: v1alpha1.ErrInvalidMaxRecordBytes,
+		},
+		"invalid backpressure": {
+			spec: v1alpha1.SinkSpec{
+				Type:           "webhook",
+				OnBackpressure: "retry",
+			},
+			expectedErr: v1alpha1.ErrInvalidBackpressure,
+		},
+		"block without tail source": {
+			spec: v1alpha1.SinkSpec{
+				Type:           "webhook",
+				OnBackpressure: v1alpha1.BackpressureBlock,
+			},
+			expectedErr: v1alpha1.ErrBlockWithoutSource,
+		},
+		"invalid severity": {
+			spec: v1alpha1.SinkSpec{
+				Type:        "webhook",
+				MinSeverity: "warn",
+			},
+			expectedErr: v1alpha1.ErrInvalidSeverity,
+		},
+		"invalid date format": {
+			spec: v1alpha1.SinkSpec{
+				Type:       "webhook",
+				DateFormat: "rfc3339",
+			},
+			expectedErr: v1alpha1.ErrInvalidDateFormat,
+		},
+		"redact": {
+			spec: v1alpha1.SinkSpec{
+				Type:   "webhook",
+				Redact: []string{"^password$", "token"},
+			},
+		},
+		"invalid redact": {
+			spec: v1alpha1.SinkSpec{
+				Type:   "webhook",
+				Redact: []string{"pass(word"},
+			},
+			expectedErr: v1alpha1.ErrInvalidRedact,
+		},
+		"matches": {
+			spec: v1alpha1.SinkSpec{
+				Type:    "webhook",
+				Matches: []string{"*_ns_nginx-*", "*_ns_redis-*"},
+			},
+		},
+		"invalid matches": {
+			spec: v1alpha1.SinkSpec{
+				Type:    "webhook",
+				Matches: []string{"*_ns_nginx-* extra"},
+			},
+			expectedErr: v1alpha1.ErrInvalidMatches,
+		},
+		"parser": {
+			spec: v1alpha1.SinkSpec{
+				Type:   "webhook",
+				Parser: "json",
+			},
+		},
+		"blank parser": {
+			spec: v1alpha1.SinkSpec{
+				Type:   "webhook",
+				Parser: " ",
+			},
+			expectedErr: v1alpha1.ErrInvalidParser,
+		},
+		"sizes": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					ChunkSize:  "512k",
+					BufferSize: "64MB",
+				},
+				TailSource: &v1alpha1.SourceSpec{
+					Path: "/var/log/audit.log",
+					Tag:  "audit",
+				},
+			},
+		},
+		"chunk size without tail source": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					ChunkSize: "512k",
+				},
+			},
+			expectedErr: v1alpha1.ErrChunkSizeWithoutSource,
+		},
+		"invalid chunk size": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					ChunkSize: "512 KiB",
+				},
+			},
+			expectedErr: v1alpha1.ErrInvalidSize,
+		},
+		"invalid buffer size": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					BufferSize: "-1",
+				},
+			},
+			expectedErr: v1alpha1.ErrInvalidSize,
+		},
+		"routes": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				Routes: []v1alpha1.RouteSpec{
+					{Field: "level", Pattern: "^error$", URL: "https://example.com/errors"},
+				},
+			},
+		},
+		"route without url": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				Routes: []v1alpha1.RouteSpec{
+					{Field: "level", Pattern: "^error$"},
+				},
+			},
+			expectedErr: v1alpha1.ErrInvalidRoutes,
+		},
+		"route with whitespace in pattern": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				Routes: []v1alpha1.RouteSpec{
+					{Field: "level", Pattern: "^some error$", URL: "https://example.com/errors"},
+				},
+			},
+			expectedErr: v1alpha1.ErrInvalidRoutes,
+		},
+		"routes with route by field": {
+			spec: v1alpha1.SinkSpec{
+				Type:         "webhook",
+				RouteByField: "kubernetes.namespace_name",
+				Routes: []v1alpha1.RouteSpec{
+					{Field: "level", Pattern: "^error$", URL: "https://example.com/errors"},
+				},
+			},
+			expectedErr: v1alpha1.ErrInvalidRoutes,
+		},
+		"route by field": {
+			spec: v1alpha1.SinkSpec{
+				Type:         "webhook",
+				RouteByField: "kubernetes.namespace_name",
+			},
+		},
+		"invalid route by field": {
+			spec: v1alpha1.SinkSpec{
+				Type:         "webhook",
+				RouteByField: "kubernetes['namespace_name']",
+			},
+			expectedErr: v1alpha1.ErrInvalidRouteByField,
+		},
+		"route by field on syslog": {
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+				RouteByField: "kubernetes.namespace_name",
+			},
+			expectedErr: v1alpha1.ErrInvalidRouteByField,
+		},
+		"invalid syslog spec": {
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					InsecureSkipVerify: &insecure,
+				},
+			},
+			expectedErr: v1alpha1.ErrInsecureSkipVerifyWithoutTLS,
+		},
+		"syslog udp with tls": {
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:      "example.com",
+					Port:      514,
+					EnableTLS: true,
+					Transport: "udp",
+				},
+			},
+			expectedErr: v1alpha1.ErrTLSOverUDP,
+		},
+		"invalid gelf spec": {
+			spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				GELFSpec: v1alpha1.GELFSpec{
+					Mode: "http",
+				},
+			},
+			expectedErr: v1alpha1.ErrInvalidGELFMode,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.spec.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Validate error not equal: Expected: %v Actual: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateSeverity(t *testing.T) {
+	testCases := map[string]struct {
+		severity    string
+		expectedErr error
+	}{
+		"unset":   {},
+		"warning": {severity: "warning"},
+		"debug":   {severity: "debug"},
+		"invalid": {
+			severity:    "warn",
+			expectedErr: v1alpha1.ErrInvalidSeverity,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := v1alpha1.ValidateSeverity(tc.severity)
+			if err != tc.expectedErr {
+				t.Errorf("ValidateSeverity error not equal: Expected: %v Actual: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestGELFSpecValidate(t *testing.T) {
+	testCases := map[string]struct {
+		mode        string
+		expectedErr error
+	}{
+		"default": {},
+		"udp":     {mode: "udp"},
+		"tcp":     {mode: "tcp"},
+		"tls":     {mode: "tls"},
+		"invalid": {
+			mode:        "http",
+			expectedErr: v1alpha1.ErrInvalidGELFMode,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := v1alpha1.GELFSpec{Mode: tc.mode}.Validate()
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Validate error not equal: Expected: %v Actual: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestSinkSpecValidateFieldError(t *testing.T) {
+	testCases := map[string]struct {
+		spec          v1alpha1.SinkSpec
+		expectedField string
+		expectedErr   error
+	}{
+		"syslog bad port": {
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 70000,
+				},
+			},
+			expectedField: "port",
+			expectedErr:   v1alpha1.ErrInvalidPort,
+		},
+		"gelf bad port": {
+			spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: -1,
+				},
+			},
+			expectedField: "port",
+			expectedErr:   v1alpha1.ErrInvalidPort,
+		},
+		"webhook bad url": {
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "/relative/path",
+				},
+			},
+			expectedField: "url",
+			expectedErr:   v1alpha1.ErrInvalidURL,
+		},
+		"bad workers": {
+			spec: v1alpha1.SinkSpec{
+				Type:    "webhook",
+				Workers: -1,
+			},
+			expectedField: "workers",
+			expectedErr:   v1alpha1.ErrInvalidWorkers,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.spec.Validate()
+			var fieldErr *v1alpha1.FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("expected a FieldError, got %v", err)
+			}
+			if fieldErr.Field != tc.expectedField {
+				t.Errorf("Field not equal: Expected: %s Actual: %s", tc.expectedField, fieldErr.Field)
+			}
+			if fieldErr.Detail != tc.expectedErr.Error() {
+				t.Errorf("Detail not equal: Expected: %s Actual: %s", tc.expectedErr, fieldErr.Detail)
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Validate error not equal: Expected: %v Actual: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -18,6 +18,8 @@ package metric
 import (
 	"bytes"
 	"log"
+	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/BurntSushi/toml"
@@ -115,13 +117,12 @@ func appendInputsAndOutputs(config *telegrafConfig, inputs, outputs []v1alpha1.M
 			continue
 		}
 
-		newInputs := make(map[string]interface{}, len(input)-1)
-		for k, v := range input {
-			if k != "type" {
-				newInputs[k] = v
-			}
+		plugin, newInputs, err := translateInput(t, input)
+		if err != nil {
+			log.Printf("Skipping invalid input: %s", err)
+			continue
 		}
-		config.Inputs[t] = append(config.Inputs[t], newInputs)
+		config.Inputs[plugin] = appendUnique(config.Inputs[plugin], newInputs)
 	}
 	for _, output := range outputs {
 		t, ok := output["type"].(string)
@@ -129,14 +130,56 @@ func appendInputsAndOutputs(config *telegrafConfig, inputs, outputs []v1alpha1.M
 			continue
 		}
 
-		newOutputs := make(map[string]interface{}, len(output)-1)
-		for k, v := range output {
-			if k != "type" {
-				newOutputs[k] = v
-			}
+		plugin, newOutputs, err := translateOutput(t, output)
+		if err != nil {
+			log.Printf("Skipping invalid output: %s", err)
+			continue
+		}
+		config.Outputs[plugin] = appendUnique(config.Outputs[plugin], newOutputs)
+	}
+}
+
+// appendUnique appends config to configs unless configs already holds a
+// config with the same normalized keys and values, so inputs and outputs
+// declared by several sinks are only rendered once.
+func appendUnique(configs []map[string]interface{}, config map[string]interface{}) []map[string]interface{} {
+	normalized := normalize(config)
+	for _, c := range configs {
+		if reflect.DeepEqual(normalize(c), normalized) {
+			return configs
 		}
-		config.Outputs[t] = append(config.Outputs[t], newOutputs)
 	}
+	return append(configs, config)
+}
+
+// normalize converts the integers of a config, whether decoded from JSON
+// as float64 or set as int, to int64 so equal configs compare equal.
+func normalize(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(tv))
+		for k, v := range tv {
+			m[k] = normalize(v)
+		}
+		return m
+	case v1alpha1.MetricSinkMap:
+		return normalize(map[string]interface{}(tv))
+	case []interface{}:
+		s := make([]interface{}, len(tv))
+		for i, v := range tv {
+			s[i] = normalize(v)
+		}
+		return s
+	case float64:
+		if tv == float64(int64(tv)) {
+			return int64(tv)
+		}
+		return tv
+	}
+	if n, ok := intValue(v); ok {
+		return int64(n)
+	}
+	return v
 }
 
 func (c *ClusterConfig) String() string {
@@ -151,7 +194,15 @@ func (c *ClusterConfig) String() string {
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	for _, cms := range c.clusterSinks {
+	// Sinks are appended in name order so the first of identical inputs
+	// and outputs is rendered consistently.
+	names := make([]string, 0, len(c.clusterSinks))
+	for name := range c.clusterSinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cms := c.clusterSinks[name]
 		appendInputsAndOutputs(&tConfig, cms.Spec.Inputs, cms.Spec.Outputs)
 	}
 
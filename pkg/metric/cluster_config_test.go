@@ -192,6 +192,86 @@ func TestMultipleSinks(t *testing.T) {
 `
 	assertEquals(t, sc, expected)
 }
+func TestIdenticalInputsAcrossSinks(t *testing.T) {
+	sc := metric.NewConfig("")
+	sink1 := v1alpha1.ClusterMetricSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-metric-sink-a",
+		},
+		Spec: v1alpha1.MetricSinkSpec{
+			Inputs: []v1alpha1.MetricSinkMap{
+				{
+					"type":            "node_exporter_metrics",
+					"host":            "127.0.0.1",
+					"port":            9100,
+					"scrape_interval": "30s",
+				},
+				{
+					"type": "cpu",
+					"baz":  1234,
+				},
+			},
+			Outputs: []v1alpha1.MetricSinkMap{
+				{
+					"type":    "influx",
+					"api_key": "some-key-1",
+				},
+			},
+		},
+	}
+	sink2 := v1alpha1.ClusterMetricSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-metric-sink-b",
+		},
+		Spec: v1alpha1.MetricSinkSpec{
+			Inputs: []v1alpha1.MetricSinkMap{
+				{
+					"type":            "node_exporter_metrics",
+					"host":            "127.0.0.1",
+					"port":            float64(9100),
+					"scrape_interval": "30s",
+				},
+				{
+					"type": "cpu",
+					"baz":  float64(1234),
+				},
+			},
+			Outputs: []v1alpha1.MetricSinkMap{
+				{
+					"type":    "influx",
+					"api_key": "some-key-1",
+				},
+				{
+					"type":    "datadog",
+					"api_key": "some-key-2",
+				},
+			},
+		},
+	}
+
+	sc.UpsertSink(sink1)
+	sc.UpsertSink(sink2)
+
+	const expected = `[inputs]
+
+  [[inputs.cpu]]
+    baz = 1234
+
+  [[inputs.prometheus]]
+    interval = "30s"
+    urls = ["http://127.0.0.1:9100/metrics"]
+
+[outputs]
+
+  [[outputs.datadog]]
+    api_key = "some-key-2"
+
+  [[outputs.influx]]
+    api_key = "some-key-1"
+`
+	assertEquals(t, sc, expected)
+}
+
 func TestDeleteSink(t *testing.T) {
 	sc := metric.NewConfig("", metric.KubernetesDefault(false))
 	sink := v1alpha1.ClusterMetricSink{
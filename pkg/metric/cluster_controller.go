@@ -18,7 +18,6 @@ package metric
 import (
 	"encoding/json"
 	"log"
-	"reflect"
 
 	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
 	coreV1 "k8s.io/api/core/v1"
@@ -143,7 +142,10 @@ func (c *ClusterController) OnDelete(o interface{}) {
 }
 
 func (c *ClusterController) OnUpdate(old, new interface{}) {
-	if !reflect.DeepEqual(old, new) {
-		c.OnAdd(new)
+	o, ok := old.(*v1alpha1.ClusterMetricSink)
+	n, nok := new.(*v1alpha1.ClusterMetricSink)
+	if ok && nok && v1alpha1.MetricSinkSpecEqual(o.Spec, n.Spec) {
+		return
 	}
+	c.OnAdd(new)
 }
@@ -18,7 +18,6 @@ package metric
 import (
 	"fmt"
 	"log"
-	"reflect"
 
 	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -102,7 +101,7 @@ func (c *Controller) OnUpdate(o, n interface{}) {
 	if !ok {
 		return
 	}
-	if reflect.DeepEqual(oms.Spec, nms.Spec) {
+	if v1alpha1.MetricSinkSpecEqual(oms.Spec, nms.Spec) {
 		return
 	}
 
@@ -0,0 +1,176 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metric
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+)
+
+const (
+	prometheusRemoteWriteType = "prometheus_remote_write"
+	prometheusScrapeType      = "prometheus_scrape"
+	nodeExporterMetricsType   = "node_exporter_metrics"
+)
+
+// ValidateSpec checks the inputs and outputs of a metric sink spec that are
+// translated by this package for the keys they require.
+func ValidateSpec(spec v1alpha1.MetricSinkSpec) error {
+	for _, input := range spec.Inputs {
+		t, ok := input["type"].(string)
+		if !ok {
+			continue
+		}
+
+		_, _, err := translateInput(t, input)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, output := range spec.Outputs {
+		t, ok := output["type"].(string)
+		if !ok {
+			continue
+		}
+
+		_, _, err := translateOutput(t, output)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// translateInput converts an input into the telegraf plugin name and
+// configuration it should be rendered as. Inputs that are not translated are
+// returned without their type key.
+func translateInput(t string, input v1alpha1.MetricSinkMap) (string, map[string]interface{}, error) {
+	switch t {
+	case prometheusScrapeType, nodeExporterMetricsType:
+		config, err := prometheusScrapeInput(t, input)
+		return "prometheus", config, err
+	default:
+		return t, withoutType(input), nil
+	}
+}
+
+// translateOutput converts an output into the telegraf plugin name and
+// configuration it should be rendered as. Outputs that are not translated
+// are returned without their type key.
+func translateOutput(t string, output v1alpha1.MetricSinkMap) (string, map[string]interface{}, error) {
+	switch t {
+	case prometheusRemoteWriteType:
+		config, err := prometheusRemoteWriteOutput(output)
+		return "http", config, err
+	default:
+		return t, withoutType(output), nil
+	}
+}
+
+func prometheusRemoteWriteOutput(output v1alpha1.MetricSinkMap) (map[string]interface{}, error) {
+	host, ok := output["host"].(string)
+	if !ok || host == "" {
+		return nil, fmt.Errorf("%s output requires a host", prometheusRemoteWriteType)
+	}
+	port, ok := intValue(output["port"])
+	if !ok || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("%s output requires a port between 1 and 65535", prometheusRemoteWriteType)
+	}
+	uri, ok := output["uri"].(string)
+	if !ok || uri == "" {
+		return nil, fmt.Errorf("%s output requires a uri", prometheusRemoteWriteType)
+	}
+
+	scheme := "http"
+	if enabled, _ := output["tls"].(bool); enabled {
+		scheme = "https"
+	}
+
+	config := map[string]interface{}{
+		"url":         fmt.Sprintf("%s://%s:%d/%s", scheme, host, port, strings.TrimPrefix(uri, "/")),
+		"data_format": "prometheusremotewrite",
+		"headers": map[string]interface{}{
+			"Content-Type":                      "application/x-protobuf",
+			"Content-Encoding":                  "snappy",
+			"X-Prometheus-Remote-Write-Version": "0.1.0",
+		},
+	}
+	if insecure, ok := output["insecure_skip_verify"].(bool); ok {
+		config["insecure_skip_verify"] = insecure
+	}
+
+	return config, nil
+}
+
+// prometheusScrapeInput translates inputs scraping a prometheus endpoint,
+// such as a node exporter, into the telegraf prometheus input.
+func prometheusScrapeInput(t string, input v1alpha1.MetricSinkMap) (map[string]interface{}, error) {
+	host, ok := input["host"].(string)
+	if !ok || host == "" {
+		return nil, fmt.Errorf("%s input requires a host", t)
+	}
+	port, ok := intValue(input["port"])
+	if !ok || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("%s input requires a port between 1 and 65535", t)
+	}
+	interval, ok := input["scrape_interval"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%s input requires a scrape_interval", t)
+	}
+	if _, err := time.ParseDuration(interval); err != nil {
+		return nil, fmt.Errorf("%s input has an invalid scrape_interval: %s", t, err)
+	}
+
+	path := "/metrics"
+	if p, ok := input["metrics_path"].(string); ok && p != "" {
+		path = "/" + strings.TrimPrefix(p, "/")
+	}
+
+	return map[string]interface{}{
+		"urls":     []string{fmt.Sprintf("http://%s:%d%s", host, port, path)},
+		"interval": interval,
+	}, nil
+}
+
+func withoutType(m v1alpha1.MetricSinkMap) map[string]interface{} {
+	config := make(map[string]interface{}, len(m)-1)
+	for k, v := range m {
+		if k != "type" {
+			config[k] = v
+		}
+	}
+	return config
+}
+
+// intValue reads an integer from a MetricSinkMap value. Values decoded from
+// JSON arrive as float64.
+func intValue(v interface{}) (int, bool) {
+	switch tv := v.(type) {
+	case int:
+		return tv, true
+	case int64:
+		return int(tv), true
+	case float64:
+		return int(tv), tv == float64(int(tv))
+	default:
+		return 0, false
+	}
+}
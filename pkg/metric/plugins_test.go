@@ -0,0 +1,206 @@
+package metric_test
+
+import (
+	"testing"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+	"github.com/knative/observability/pkg/metric"
+)
+
+func TestPrometheusRemoteWriteOutput(t *testing.T) {
+	sc := metric.NewConfig("")
+	sink := v1alpha1.ClusterMetricSink{
+		Spec: v1alpha1.MetricSinkSpec{
+			Inputs: []v1alpha1.MetricSinkMap{
+				{
+					"type": "cpu",
+				},
+			},
+			Outputs: []v1alpha1.MetricSinkMap{
+				{
+					"type": "prometheus_remote_write",
+					"host": "prometheus.example.com",
+					"port": 9090,
+					"uri":  "/api/v1/write",
+				},
+				{
+					"type":                 "prometheus_remote_write",
+					"host":                 "secure.example.com",
+					"port":                 float64(443),
+					"uri":                  "api/v1/write",
+					"tls":                  true,
+					"insecure_skip_verify": true,
+				},
+			},
+		},
+	}
+
+	sc.UpsertSink(sink)
+
+	const expected = `[inputs]
+
+  [[inputs.cpu]]
+
+[outputs]
+
+  [[outputs.http]]
+    data_format = "prometheusremotewrite"
+    url = "http://prometheus.example.com:9090/api/v1/write"
+    [outputs.http.headers]
+      Content-Encoding = "snappy"
+      Content-Type = "application/x-protobuf"
+      X-Prometheus-Remote-Write-Version = "0.1.0"
+
+  [[outputs.http]]
+    data_format = "prometheusremotewrite"
+    insecure_skip_verify = true
+    url = "https://secure.example.com:443/api/v1/write"
+    [outputs.http.headers]
+      Content-Encoding = "snappy"
+      Content-Type = "application/x-protobuf"
+      X-Prometheus-Remote-Write-Version = "0.1.0"
+`
+
+	assertEquals(t, sc, expected)
+}
+
+func TestPrometheusRemoteWriteOutputValidation(t *testing.T) {
+	tests := map[string]v1alpha1.MetricSinkMap{
+		"missing host": {
+			"type": "prometheus_remote_write",
+			"port": 9090,
+			"uri":  "/api/v1/write",
+		},
+		"missing port": {
+			"type": "prometheus_remote_write",
+			"host": "prometheus.example.com",
+			"uri":  "/api/v1/write",
+		},
+		"invalid port": {
+			"type": "prometheus_remote_write",
+			"host": "prometheus.example.com",
+			"port": 70000,
+			"uri":  "/api/v1/write",
+		},
+		"missing uri": {
+			"type": "prometheus_remote_write",
+			"host": "prometheus.example.com",
+			"port": 9090,
+		},
+	}
+
+	for name, output := range tests {
+		t.Run(name, func(t *testing.T) {
+			spec := v1alpha1.MetricSinkSpec{
+				Outputs: []v1alpha1.MetricSinkMap{output},
+			}
+			if err := metric.ValidateSpec(spec); err == nil {
+				t.Error("expected validation error")
+			}
+
+			sc := metric.NewConfig("")
+			sc.UpsertSink(v1alpha1.ClusterMetricSink{
+				Spec: v1alpha1.MetricSinkSpec{
+					Inputs: []v1alpha1.MetricSinkMap{
+						{"type": "cpu"},
+					},
+					Outputs: []v1alpha1.MetricSinkMap{output},
+				},
+			})
+			assertEquals(t, sc, `[inputs]
+
+  [[inputs.cpu]]
+
+[outputs]
+
+  [[outputs.discard]]
+`)
+		})
+	}
+}
+
+func TestNodeExporterInput(t *testing.T) {
+	sc := metric.NewConfig("")
+	sink := v1alpha1.ClusterMetricSink{
+		Spec: v1alpha1.MetricSinkSpec{
+			Inputs: []v1alpha1.MetricSinkMap{
+				{
+					"type":            "node_exporter_metrics",
+					"host":            "node-exporter.monitoring",
+					"port":            9100,
+					"scrape_interval": "30s",
+				},
+				{
+					"type":            "prometheus_scrape",
+					"host":            "app.example",
+					"port":            float64(8080),
+					"scrape_interval": "1m",
+					"metrics_path":    "stats/prometheus",
+				},
+			},
+			Outputs: []v1alpha1.MetricSinkMap{
+				{
+					"type":    "datadog",
+					"api_key": "some-key",
+				},
+			},
+		},
+	}
+
+	sc.UpsertSink(sink)
+
+	const expected = `[inputs]
+
+  [[inputs.prometheus]]
+    interval = "30s"
+    urls = ["http://node-exporter.monitoring:9100/metrics"]
+
+  [[inputs.prometheus]]
+    interval = "1m"
+    urls = ["http://app.example:8080/stats/prometheus"]
+
+[outputs]
+
+  [[outputs.datadog]]
+    api_key = "some-key"
+`
+
+	assertEquals(t, sc, expected)
+}
+
+func TestNodeExporterInputValidation(t *testing.T) {
+	tests := map[string]v1alpha1.MetricSinkMap{
+		"missing interval": {
+			"type": "node_exporter_metrics",
+			"host": "node-exporter.monitoring",
+			"port": 9100,
+		},
+		"invalid interval": {
+			"type":            "node_exporter_metrics",
+			"host":            "node-exporter.monitoring",
+			"port":            9100,
+			"scrape_interval": "often",
+		},
+		"missing host": {
+			"type":            "prometheus_scrape",
+			"port":            9100,
+			"scrape_interval": "30s",
+		},
+		"missing port": {
+			"type":            "prometheus_scrape",
+			"host":            "node-exporter.monitoring",
+			"scrape_interval": "30s",
+		},
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			spec := v1alpha1.MetricSinkSpec{
+				Inputs: []v1alpha1.MetricSinkMap{input},
+			}
+			if err := metric.ValidateSpec(spec); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
@@ -16,8 +16,6 @@ limitations under the License.
 package sink
 
 import (
-	"reflect"
-
 	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
 )
 
@@ -80,7 +78,7 @@ func (c *ClusterController) OnUpdate(old, new interface{}) {
 	if !ok {
 		return
 	}
-	if !reflect.DeepEqual(o.Spec, n.Spec) {
+	if !v1alpha1.SinkSpecEqual(o.Spec, n.Spec) {
 		c.OnAdd(new)
 	}
 }
@@ -27,6 +27,7 @@ import (
 )
 
 func TestClusterSinkModification(t *testing.T) {
+	insecure := true
 	var tests = []struct {
 		name       string
 		operations []string
@@ -40,7 +41,7 @@ func TestClusterSinkModification(t *testing.T) {
 				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 12345}},
 			},
 			[]string{
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\"}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
 			},
 		},
 		{
@@ -50,17 +51,17 @@ func TestClusterSinkModification(t *testing.T) {
 				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 12345, EnableTLS: true}},
 			},
 			[]string{
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"tls\":{},\"name\":\"sink-example.com\"}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"tls\":{},\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
 			},
 		},
 		{
 			"Add a single TLS sink with insecure skip verify set",
 			[]string{"add"},
 			[]v1alpha1.SinkSpec{
-				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 12345, EnableTLS: true, InsecureSkipVerify: true}},
+				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 12345, EnableTLS: true, InsecureSkipVerify: &insecure}},
 			},
 			[]string{
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"tls\":{\"insecure_skip_verify\":true},\"name\":\"sink-example.com\"}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"tls\":{\"insecure_skip_verify\":true},\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
 			},
 		},
 		{
@@ -71,8 +72,8 @@ func TestClusterSinkModification(t *testing.T) {
 				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "test.com", Port: 4567}},
 			},
 			[]string{
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\"}]\n",
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\"},{\"addr\":\"test.com:4567\",\"name\":\"sink-test.com\"}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]},{\"addr\":\"test.com:4567\",\"name\":\"sink-test.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
 			},
 		},
 		{
@@ -83,8 +84,8 @@ func TestClusterSinkModification(t *testing.T) {
 				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 4567}},
 			},
 			[]string{
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\"}]\n",
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:4567\",\"name\":\"sink-example.com\"}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:4567\",\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
 			},
 		},
 		{
@@ -95,8 +96,8 @@ func TestClusterSinkModification(t *testing.T) {
 				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 12346}},
 			},
 			[]string{
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\"}]\n",
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12346\",\"name\":\"sink-example.com\"}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12346\",\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
 			},
 		},
 		{
@@ -107,7 +108,7 @@ func TestClusterSinkModification(t *testing.T) {
 				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 12345}},
 			},
 			[]string{
-				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\"}]\n",
+				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks []\n    ClusterSinks [{\"addr\":\"example.com:12345\",\"name\":\"sink-example.com\",\"exclude_namespaces\":[\"kube-system\",\"kube-public\",\"kube-node-lease\"]}]\n",
 				"\n[OUTPUT]\n    Name null\n    Match *\n    StatsAddr 127.0.0.1:5000\n",
 			},
 		},
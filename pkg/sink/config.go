@@ -5,7 +5,7 @@ Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
 You may obtain a copy of the License at
 
-    http://www.apache.org/licenses/LICENSE-2.0
+	http://www.apache.org/licenses/LICENSE-2.0
 
 Unless required by applicable law or agreed to in writing, software
 distributed under the License is distributed on an "AS IS" BASIS,
@@ -16,12 +16,21 @@ limitations under the License.
 package sink
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/url"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"text/template"
+	"time"
 
 	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
 )
@@ -29,109 +38,644 @@ import (
 const nullConfig = `
 [OUTPUT]
     Name null
-    Match *
+    Match %s
     StatsAddr %s
 `
 
+// tailInputConfig reads the files of the TailSource of a cluster sink.
+const tailInputConfig = `
+[INPUT]
+    Name tail
+    Path %s
+    Tag %s
+`
+
+const dummyInputConfig = `
+[INPUT]
+    Name dummy
+    Tag %s
+    Dummy %s
+`
+
 const httpOutputConfig = `
 [OUTPUT]
     Name http
-    Match %s
+    %s
     Format json
     Host %s
-    Port %s
-    URI %s
+`
+
+const gelfOutputConfig = `
+[OUTPUT]
+    Name gelf
+    %s
+    Host %s
+    Port %d
+    Mode %s
+    Gelf_Short_Message_Key log
+`
+
+const otlpOutputConfig = `
+[OUTPUT]
+    Name opentelemetry
+    %s
+    Host %s
+    Port %d
+    Logs_uri %s
+`
+
+const datadogOutputConfig = `
+[OUTPUT]
+    Name datadog
+    %s
+    Host %s
+    tls On
+    apikey %s
+`
+
+const azureOutputConfig = `
+[OUTPUT]
+    Name azure
+    %s
+    Customer_ID %s
+    Shared_Key %s
+`
+
+// rewriteTagFilterConfig re-emits the records of a cluster sink with a
+// RouteByField tagged by the value of the field. Records already re-emitted
+// are excluded so they are not rewritten again.
+const rewriteTagFilterConfig = `
+[FILTER]
+    Name rewrite_tag
+    %s
+    Rule %s ^(.+)$ %s$1 true
+    Emitter_Name %s
+`
+
+// isolateFilterConfig copies the records of a sink filtering them to the
+// tag of the sink, see isolationTag. Rules only match keys holding strings,
+// so records without a log key are copied by their namespace.
+const isolateFilterConfig = `
+[FILTER]
+    Name rewrite_tag
+    %s
+    Rule $log .* %[2]s true
+    Rule $kubernetes['namespace_name'] .* %[2]s true
+    Emitter_Name %s
+`
+
+// routeTagHeader is the header the webhook of a cluster sink with a
+// RouteByField receives the tag of each record in.
+const routeTagHeader = "X-Route-Tag"
+
+// infiniteRetriesDirective makes an output retry failed flushes without limit.
+const infiniteRetriesDirective = "Retry_Limit no_limits"
+
+// pauseOnOverlimitDirective makes an input pause rather than drop records
+// when its buffered chunks are over the limit.
+const pauseOnOverlimitDirective = "storage.pause_on_chunks_overlimit On"
+
+// sizeDirectives returns the directive setting the BufferSize of an output,
+// if any. The ChunkSize is set on the tail input, see sourceConfig.
+func sizeDirectives(spec v1alpha1.SinkSpec) []string {
+	var directives []string
+	if spec.BufferSize != "" {
+		directives = append(directives, "storage.total_limit_size "+spec.BufferSize)
+	}
+	return directives
+}
+
+// sampleFilterConfig keeps roughly one in every N matched records and drops
+// the rest.
+const sampleFilterConfig = `
+[FILTER]
+    Name lua
+    %s
+    call sample
+    code function sample(tag, timestamp, record) if math.random(%d) == 1 then return 0, timestamp, record end return -1, 0, 0 end
+`
+
+// truncateFilterConfig truncates the log key of records to a sink's
+// MaxRecordBytes.
+const truncateFilterConfig = `
+[FILTER]
+    Name lua
+    %s
+    call truncate
+    code function truncate(tag, timestamp, record) local log = record["log"] if type(log) == "string" and #log > %[2]d then record["log"] = string.sub(log, 1, %[2]d) return 1, timestamp, record end return 0, timestamp, record end
+`
+
+// severityFilterConfig drops records whose severity matches the regex of
+// severities below a sink's MinSeverity.
+const severityFilterConfig = `
+[FILTER]
+    Name grep
+    %s
+    Exclude severity ^(%s)$
+`
+
+// parserFilterConfig parses the log key of records with a named parser,
+// keeping the other keys.
+const parserFilterConfig = `
+[FILTER]
+    Name parser
+    %s
+    Key_Name log
+    Parser %s
+    Reserve_Data On
+`
+
+// redactFilterConfig removes the keys matching a regex from records. The
+// Remove_regex directive is repeated for every pattern.
+const redactFilterConfig = `
+[FILTER]
+    Name modify
     %s
 `
 
+// HTTPPluginVersion selects the directive names emitted for the Fluent Bit
+// http output plugin.
+type HTTPPluginVersion string
+
+const (
+	HTTPPluginV1 HTTPPluginVersion = "v1"
+	HTTPPluginV2 HTTPPluginVersion = "v2"
+)
+
+// ParseHTTPPluginVersion returns the HTTPPluginVersion named by s. An error
+// is returned if s is not a supported version.
+func ParseHTTPPluginVersion(s string) (HTTPPluginVersion, error) {
+	switch v := HTTPPluginVersion(s); v {
+	case HTTPPluginV1, HTTPPluginV2:
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown http plugin version %q, must be %s or %s", s, HTTPPluginV1, HTTPPluginV2)
+}
+
+// KeyCase selects the casing of the directive keys in the rendered config.
+type KeyCase int
+
+const (
+	// TitleCase renders keys as they are documented by Fluent Bit, e.g.
+	// Name and Match.
+	TitleCase KeyCase = iota
+	// LowerCase renders every key in lower case, e.g. name and match.
+	LowerCase
+)
+
 type Config struct {
-	mu           sync.Mutex
+	mu           sync.RWMutex
 	statsAddr    string
 	sinks        map[string]*v1alpha1.LogSink
 	clusterSinks map[string]*v1alpha1.ClusterLogSink
+	// draining holds when sinks deleted with DeleteSinkWithDrain are
+	// removed, keyed like sinks.
+	draining      map[string]time.Time
+	onChange      []func()
+	sinkTemplates []*template.Template
+	namespaces    map[string]bool
+	// templatedSinks holds the sinks expanded from sinkTemplates for each
+	// namespace added with AddNamespace, keyed like sinks.
+	templatedSinks map[string]*v1alpha1.LogSink
+
+	opts                      []ConfigOption
+	httpPluginVersion         HTTPPluginVersion
+	keyCase                   KeyCase
+	format                    Format
+	maxBytes                  int
+	maxSinks                  int
+	healthTag                 string
+	healthURL                 string
+	defaultEnableTLS          bool
+	defaultInsecureSkipVerify bool
+	sortByAddr                bool
+	sinkComments              bool
+	sinkAliases               bool
+	naturalSort               bool
+	statsAlias                string
+	nullMatch                 string
+	suppressNullConfig        bool
+	allowedHosts              []string
+	matchTemplate             *template.Template
+	renderers                 map[string]OutputRenderer
+
+	lastRenderErr error
+	retryLimits   map[string]int
+	secrets       map[string]string
+	// versions holds the versions set with SetSinkVersion, keyed like
+	// sinks.
+	versions map[string]string
+}
+
+type ConfigOption func(*Config)
+
+// DefaultMatchTemplate matches the tags of records from a namespace when
+// tags are underscore delimited, e.g. kube_some-namespace_some-pod.
+const DefaultMatchTemplate = "*_{{.Namespace}}_*"
+
+// WithHTTPPluginVersion sets the http plugin version the rendered directives
+// target. Defaults to HTTPPluginV1.
+func WithHTTPPluginVersion(v HTTPPluginVersion) ConfigOption {
+	return func(c *Config) {
+		c.httpPluginVersion = v
+	}
 }
 
-func NewConfig(statsAddr string) *Config {
-	return &Config{
-		statsAddr:    statsAddr,
-		sinks:        make(map[string]*v1alpha1.LogSink),
-		clusterSinks: make(map[string]*v1alpha1.ClusterLogSink),
+// WithKeyCase sets the casing of every directive key in the rendered config.
+// Defaults to TitleCase.
+func WithKeyCase(k KeyCase) ConfigOption {
+	return func(c *Config) {
+		c.keyCase = k
 	}
 }
 
-func (sc *Config) UpsertSink(s *v1alpha1.LogSink) {
-	sc.mu.Lock()
-	defer sc.mu.Unlock()
-	sc.sinks[key(s)] = s
+// WithMaxBytes sets the size limit RenderChecked enforces on the rendered
+// config. A limit of 0, the default, is unlimited.
+func WithMaxBytes(n int) ConfigOption {
+	return func(c *Config) {
+		c.maxBytes = n
+	}
 }
 
-func (sc *Config) UpsertClusterSink(cs *v1alpha1.ClusterLogSink) {
+// WithMaxSinks sets the number of sinks and cluster sinks, together, that
+// may be tracked. Upserting a sink that is not yet tracked fails once the
+// limit is reached. A limit of 0, the default, is unlimited.
+func WithMaxSinks(n int) ConfigOption {
+	return func(c *Config) {
+		c.maxSinks = n
+	}
+}
+
+// ErrMaxSinks is returned by UpsertSinkChecked, UpsertClusterSinkChecked and
+// the methods tracking many sinks at once when tracking the sinks would
+// exceed the limit set with WithMaxSinks.
+var ErrMaxSinks = errors.New("maximum number of sinks reached")
+
+// WithHealthOutput adds an http output sending the records tagged with tag
+// to url, e.g. heartbeats for monitoring Fluent Bit itself. It is rendered
+// whether or not there are any sinks.
+func WithHealthOutput(tag, url string) ConfigOption {
+	return func(c *Config) {
+		c.healthTag = tag
+		c.healthURL = url
+	}
+}
+
+// WithDefaultEnableTLS enables TLS for every syslog sink that does not enable
+// it itself or opt out with DisableTLS.
+func WithDefaultEnableTLS(enabled bool) ConfigOption {
+	return func(c *Config) {
+		c.defaultEnableTLS = enabled
+	}
+}
+
+// WithAllowedHosts restricts the hosts sinks may send to. Validate reports
+// every sink whose destination host is not one of hosts. A host starting
+// with a dot, such as .example.com, allows any subdomain. Every host is
+// allowed by default.
+func WithAllowedHosts(hosts ...string) ConfigOption {
+	return func(c *Config) {
+		c.allowedHosts = make([]string, 0, len(hosts))
+		for _, h := range hosts {
+			c.allowedHosts = append(c.allowedHosts, normalizeHost(h))
+		}
+	}
+}
+
+// WithStatsAlias sets the Alias of the null output rendered for stats, so
+// the outputs of several configs loaded by one Fluent Bit can be told
+// apart. No alias is rendered by default.
+func WithStatsAlias(alias string) ConfigOption {
+	return func(c *Config) {
+		c.statsAlias = alias
+	}
+}
+
+// WithNullMatch sets the Match pattern of the null output rendered for
+// stats, e.g. a tag only used by this config when it is composed with other
+// configs loaded by the same Fluent Bit. The null output matches every
+// record by default.
+func WithNullMatch(match string) ConfigOption {
+	return func(c *Config) {
+		c.nullMatch = match
+	}
+}
+
+// WithSuppressNullConfig leaves out the null output rendered when there are
+// no enabled sinks, so the config is empty rather than discarding records
+// another config loaded by the same Fluent Bit, e.g. with @INCLUDE, routes
+// to its outputs. The null output is rendered by default.
+func WithSuppressNullConfig(suppress bool) ConfigOption {
+	return func(c *Config) {
+		c.suppressNullConfig = suppress
+	}
+}
+
+// WithSinkComments prefixes every output with comments naming the sinks it
+// was rendered for, e.g. "# sink: some-namespace/some-name". Comments are
+// not rendered by default since not every parser of the config accepts them.
+func WithSinkComments(enabled bool) ConfigOption {
+	return func(c *Config) {
+		c.sinkComments = enabled
+	}
+}
+
+// WithSinkAliases renders an Alias in every output naming the sink it was
+// rendered for, so the output metrics of Fluent Bit can be told apart. The
+// outputs of a sink are aliased ns_<namespace>_<name>, or cluster_<name> for
+// a cluster sink, with _1, _2 and so on appended from its second output.
+// Since names cannot contain underscores the aliases do not collide. The
+// shared syslog output is aliased syslog and the health output health.
+// Aliases are not rendered by default.
+func WithSinkAliases(enabled bool) ConfigOption {
+	return func(c *Config) {
+		c.sinkAliases = enabled
+	}
+}
+
+// WithNaturalSort orders the outputs and filters of sinks by their names
+// compared numerically where they contain digits, e.g. sink2 before sink10,
+// rather than lexically. Sinks are ordered lexically by default.
+func WithNaturalSort(enabled bool) ConfigOption {
+	return func(c *Config) {
+		c.naturalSort = enabled
+	}
+}
+
+// WithDefaultInsecureSkipVerify sets whether syslog sinks that have TLS
+// enabled by WithDefaultEnableTLS verify the server certificate. It does not
+// apply to sinks that set InsecureSkipVerify themselves.
+func WithDefaultInsecureSkipVerify(skip bool) ConfigOption {
+	return func(c *Config) {
+		c.defaultInsecureSkipVerify = skip
+	}
+}
+
+// WithSortByAddr orders the entries of the syslog output by their address
+// and then by name, grouping the sinks sending to the same collector. By
+// default sinks are ordered by namespace and then by name.
+func WithSortByAddr(enabled bool) ConfigOption {
+	return func(c *Config) {
+		c.sortByAddr = enabled
+	}
+}
+
+// WithMatchTemplate sets the template used to build the Match pattern of the
+// outputs of namespaced sinks. The template is given the namespace as
+// {{.Namespace}}, e.g. "kube.{{.Namespace}}.*" for dot delimited tags. An
+// invalid template is logged and DefaultMatchTemplate is used instead.
+func WithMatchTemplate(text string) ConfigOption {
+	return func(c *Config) {
+		t, err := template.New("match").Parse(text)
+		if err != nil {
+			log.Printf("invalid match template %q: %s", text, err)
+			return
+		}
+		c.matchTemplate = t
+	}
+}
+
+func NewConfig(statsAddr string, opts ...ConfigOption) *Config {
+	c := &Config{
+		statsAddr:         statsAddr,
+		sinks:             make(map[string]*v1alpha1.LogSink),
+		clusterSinks:      make(map[string]*v1alpha1.ClusterLogSink),
+		draining:          make(map[string]time.Time),
+		namespaces:        make(map[string]bool),
+		templatedSinks:    make(map[string]*v1alpha1.LogSink),
+		opts:              opts,
+		httpPluginVersion: HTTPPluginV1,
+		retryLimits:       make(map[string]int),
+		secrets:           make(map[string]string),
+		versions:          make(map[string]string),
+		matchTemplate:     template.Must(template.New("match").Parse(DefaultMatchTemplate)),
+	}
+
+	c.renderers = c.builtinRenderers()
+
+	for _, o := range opts {
+		o(c)
+	}
+
+	return c
+}
+
+// NewConfigWithSinks returns a Config tracking copies of sinks and
+// clusterSinks, e.g. listed from an informer cache on startup, as if each
+// had been passed to UpsertSink or UpsertClusterSink. ErrMaxSinks is returned
+// when there are more than the limit set with WithMaxSinks.
+func NewConfigWithSinks(
+	statsAddr string,
+	sinks []*v1alpha1.LogSink,
+	clusterSinks []*v1alpha1.ClusterLogSink,
+	opts ...ConfigOption,
+) (*Config, error) {
+	c := NewConfig(statsAddr, opts...)
+	if err := c.ReplaceAll(sinks, clusterSinks); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewConfigChecked is like NewConfig but returns an error when statsAddr is
+// not a host:port address with a port between 1 and 65535. The host may be
+// empty.
+func NewConfigChecked(statsAddr string, opts ...ConfigOption) (*Config, error) {
+	_, port, err := net.SplitHostPort(statsAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stats address %q: %s", statsAddr, err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return nil, fmt.Errorf("invalid stats address %q: invalid port %q", statsAddr, port)
+	}
+	return NewConfig(statsAddr, opts...), nil
+}
+
+// StatsAddr returns the address rendered for the stats output.
+func (sc *Config) StatsAddr() string {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
-	sc.clusterSinks[clusterKey(cs)] = cs
+	return sc.statsAddr
 }
 
-func (sc *Config) DeleteSink(s *v1alpha1.LogSink) {
+// SetStatsAddr changes the address rendered for the stats output by
+// subsequent calls to String.
+func (sc *Config) SetStatsAddr(addr string) {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
-	delete(sc.sinks, key(s))
+	sc.statsAddr = addr
 }
 
-func (sc *Config) DeleteClusterSink(s *v1alpha1.ClusterLogSink) {
+// OnChange registers f to be called whenever the tracked sinks or cluster
+// sinks change, e.g. to render the config again. It is not called when a sink
+// is upserted with the spec it already has. Callbacks are called in the order
+// they were registered, outside of the lock of the config so they may call
+// its methods.
+func (sc *Config) OnChange(f func()) {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
-	delete(sc.clusterSinks, clusterKey(s))
+	sc.onChange = append(sc.onChange, f)
 }
 
-func (sc *Config) String() string {
+// notify calls the OnChange callbacks if changed. It must be called without
+// holding the lock.
+func (sc *Config) notify(changed bool) {
+	if !changed {
+		return
+	}
 	sc.mu.Lock()
-	defer sc.mu.Unlock()
-	if len(sc.sinks)+len(sc.clusterSinks) == 0 {
-		return fmt.Sprintf(nullConfig, sc.statsAddr)
+	callbacks := append([]func(){}, sc.onChange...)
+	sc.mu.Unlock()
+	for _, f := range callbacks {
+		f()
 	}
-	return sc.syslogConfig() + sc.webhookConfig()
 }
 
-func (sc *Config) webhookConfig() string {
-	var config string
-	for _, s := range sc.sinks {
-		if s.Spec.Type != "webhook" {
-			continue
-		}
+// UpsertSink tracks the sink, replacing the tracked sink with the same
+// namespace and name. A sink exceeding the limit set with WithMaxSinks is
+// logged and not tracked.
+func (sc *Config) UpsertSink(s *v1alpha1.LogSink) {
+	if err := sc.UpsertSinkChecked(s); err != nil {
+		log.Printf("unable to upsert sink %s/%s: %s", s.Namespace, s.Name, err)
+	}
+}
 
-		config += buildHTTPConfig(s.Namespace, s.Spec.URL, false)
+// UpsertSinkChecked is like UpsertSink but returns ErrMaxSinks rather than
+// logging it.
+func (sc *Config) UpsertSinkChecked(s *v1alpha1.LogSink) error {
+	sc.mu.Lock()
+	existing, ok := sc.sinks[key(s)]
+	if !ok && sc.atMaxSinks() {
+		sc.mu.Unlock()
+		return ErrMaxSinks
 	}
+	changed := !ok || !v1alpha1.SinkSpecEqual(existing.Spec, s.Spec)
+	sc.sinks[key(s)] = s.DeepCopy()
+	delete(sc.draining, key(s))
+	sc.mu.Unlock()
+	sc.notify(changed)
+	return nil
+}
 
-	for _, s := range sc.clusterSinks {
-		if s.Spec.Type != "webhook" {
-			continue
-		}
+// UpsertClusterSink tracks the cluster sink, replacing the tracked cluster
+// sink with the same name. A cluster sink exceeding the limit set with
+// WithMaxSinks is logged and not tracked.
+func (sc *Config) UpsertClusterSink(cs *v1alpha1.ClusterLogSink) {
+	if err := sc.UpsertClusterSinkChecked(cs); err != nil {
+		log.Printf("unable to upsert cluster sink %s: %s", cs.Name, err)
+	}
+}
 
-		config += buildHTTPConfig("", s.Spec.URL, true)
+// UpsertClusterSinkChecked is like UpsertClusterSink but returns ErrMaxSinks
+// rather than logging it.
+func (sc *Config) UpsertClusterSinkChecked(cs *v1alpha1.ClusterLogSink) error {
+	sc.mu.Lock()
+	existing, ok := sc.clusterSinks[clusterKey(cs)]
+	if !ok && sc.atMaxSinks() {
+		sc.mu.Unlock()
+		return ErrMaxSinks
 	}
+	changed := !ok || !v1alpha1.SinkSpecEqual(existing.Spec, cs.Spec)
+	sc.clusterSinks[clusterKey(cs)] = cs.DeepCopy()
+	sc.mu.Unlock()
+	sc.notify(changed)
+	return nil
+}
 
-	return config
+// atMaxSinks reports whether no more sinks may be tracked.
+func (sc *Config) atMaxSinks() bool {
+	return sc.maxSinks > 0 && len(sc.sinks)+len(sc.clusterSinks) >= sc.maxSinks
 }
 
-func (sc *Config) syslogConfig() string {
-	sinks := make([]sink, 0, len(sc.sinks))
-	for _, s := range sc.sinks {
-		if s.Spec.Type != "syslog" {
+// overMaxSinks reports whether n sinks and cluster sinks, together, are more
+// than may be tracked.
+func (sc *Config) overMaxSinks(n int) bool {
+	return sc.maxSinks > 0 && n > sc.maxSinks
+}
+
+func (sc *Config) DeleteSink(s *v1alpha1.LogSink) {
+	sc.mu.Lock()
+	_, changed := sc.sinks[key(s)]
+	delete(sc.sinks, key(s))
+	delete(sc.draining, key(s))
+	sc.mu.Unlock()
+	sc.notify(changed)
+}
+
+// DeleteSinkWithDrain marks the sink as draining rather than deleting it, so
+// it keeps being rendered while its buffered records are flushed. It is
+// removed by the first call to PurgeExpired after the grace period. Upserting
+// the sink again cancels the removal.
+func (sc *Config) DeleteSinkWithDrain(s *v1alpha1.LogSink, grace time.Duration) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if _, ok := sc.sinks[key(s)]; !ok {
+		return
+	}
+	sc.draining[key(s)] = time.Now().Add(grace)
+}
+
+// PurgeExpired removes the draining sinks whose grace period ended at or
+// before now. It reports whether any sink was removed.
+func (sc *Config) PurgeExpired(now time.Time) bool {
+	sc.mu.Lock()
+	var purged bool
+	for k, expiry := range sc.draining {
+		if expiry.After(now) {
 			continue
 		}
+		delete(sc.sinks, k)
+		delete(sc.draining, k)
+		purged = true
+	}
+	sc.mu.Unlock()
+	sc.notify(purged)
+	return purged
+}
 
-		var tlsConfig *tls
-		if s.Spec.EnableTLS {
-			tlsConfig = &tls{
-				InsecureSkipVerify: s.Spec.InsecureSkipVerify,
-			}
+func (sc *Config) DeleteClusterSink(s *v1alpha1.ClusterLogSink) {
+	sc.mu.Lock()
+	_, changed := sc.clusterSinks[clusterKey(s)]
+	delete(sc.clusterSinks, clusterKey(s))
+	sc.mu.Unlock()
+	sc.notify(changed)
+}
+
+// GetSink returns a copy of the tracked LogSink with the given namespace and
+// name.
+func (sc *Config) GetSink(namespace, name string) (*v1alpha1.LogSink, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	s, ok := sc.sinks[fmt.Sprintf("%s|%s", namespace, name)]
+	if !ok {
+		return nil, false
+	}
+	return s.DeepCopy(), true
+}
+
+// GetClusterSink returns a copy of the tracked ClusterLogSink with the given
+// name.
+func (sc *Config) GetClusterSink(name string) (*v1alpha1.ClusterLogSink, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for _, cs := range sc.clusterSinks {
+		if cs.Name == name {
+			return cs.DeepCopy(), true
 		}
-		sinks = append(sinks, sink{
-			Addr:      fmt.Sprintf("%s:%d", s.Spec.Host, s.Spec.Port),
-			Namespace: canonicalNamespace(s.Namespace),
-			TLS:       tlsConfig,
-			Name:      s.Name,
-		})
+	}
+	return nil, false
+}
+
+// ForEachSink calls f with a copy of every tracked LogSink, ordered by
+// namespace and name, until f returns false. It holds a read lock while
+// iterating, so f must not modify the Config.
+func (sc *Config) ForEachSink(f func(*v1alpha1.LogSink) bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	sinks := make([]*v1alpha1.LogSink, 0, len(sc.sinks))
+	for _, s := range sc.sinks {
+		sinks = append(sinks, s)
 	}
 	sort.Slice(sinks, func(i, j int) bool {
 		if sinks[i].Namespace != sinks[j].Namespace {
@@ -139,114 +683,2377 @@ func (sc *Config) syslogConfig() string {
 		}
 		return sinks[i].Name < sinks[j].Name
 	})
-	// TODO: don't return null config yet. just set to empty json
-	sinksJSON, err := json.Marshal(sinks)
-	if err != nil {
-		log.Print("unable to marshal sinks")
-		sinksJSON = []byte("[]")
+	for _, s := range sinks {
+		if !f(s.DeepCopy()) {
+			return
+		}
 	}
+}
 
-	clusterSinks := make([]sink, 0, len(sc.clusterSinks))
+// ForEachClusterSink calls f with a copy of every tracked ClusterLogSink,
+// ordered by name, like ForEachSink.
+func (sc *Config) ForEachClusterSink(f func(*v1alpha1.ClusterLogSink) bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	sinks := make([]*v1alpha1.ClusterLogSink, 0, len(sc.clusterSinks))
 	for _, s := range sc.clusterSinks {
-		if s.Spec.Type != "syslog" {
-			continue
-		}
-
-		var tlsConfig *tls
-		if s.Spec.EnableTLS {
-			tlsConfig = &tls{
-				InsecureSkipVerify: s.Spec.InsecureSkipVerify,
-			}
-		}
-		clusterSinks = append(clusterSinks, sink{
-			Addr: fmt.Sprintf("%s:%d", s.Spec.Host, s.Spec.Port),
-			TLS:  tlsConfig,
-			Name: s.Name,
-		})
+		sinks = append(sinks, s)
 	}
-	sort.Slice(clusterSinks, func(i, j int) bool {
-		return clusterSinks[i].Name < clusterSinks[j].Name
+	sort.Slice(sinks, func(i, j int) bool {
+		return sinks[i].Name < sinks[j].Name
 	})
-	clusterSinksJSON, err := json.Marshal(clusterSinks)
-	if err != nil {
-		log.Print("unable to marshal cluster sinks")
-		clusterSinksJSON = []byte("[]")
+	for _, s := range sinks {
+		if !f(s.DeepCopy()) {
+			return
+		}
 	}
+}
 
-	if len(sinks)+len(clusterSinks) == 0 {
-		return ""
+// Reset removes every tracked sink and cluster sink.
+func (sc *Config) Reset() {
+	sc.mu.Lock()
+	changed := len(sc.sinks)+len(sc.clusterSinks) > 0
+	sc.sinks = make(map[string]*v1alpha1.LogSink)
+	sc.clusterSinks = make(map[string]*v1alpha1.ClusterLogSink)
+	sc.draining = make(map[string]time.Time)
+	sc.mu.Unlock()
+	sc.notify(changed)
+}
+
+// ReplaceAll replaces every tracked sink and cluster sink with the given
+// ones. ErrMaxSinks is returned, and nothing replaced, when there are more
+// than the limit set with WithMaxSinks.
+func (sc *Config) ReplaceAll(sinks []*v1alpha1.LogSink, clusterSinks []*v1alpha1.ClusterLogSink) error {
+	newSinks := make(map[string]*v1alpha1.LogSink, len(sinks))
+	for _, s := range sinks {
+		newSinks[key(s)] = s.DeepCopy()
+	}
+	newClusterSinks := make(map[string]*v1alpha1.ClusterLogSink, len(clusterSinks))
+	for _, cs := range clusterSinks {
+		newClusterSinks[clusterKey(cs)] = cs.DeepCopy()
 	}
 
-	return fmt.Sprintf(`
-[OUTPUT]
-    Name syslog
-    Match *
-    StatsAddr %s
-    Sinks %s
-    ClusterSinks %s
-`, sc.statsAddr, sinksJSON, clusterSinksJSON)
+	sc.mu.Lock()
+	if sc.overMaxSinks(len(newSinks) + len(newClusterSinks)) {
+		sc.mu.Unlock()
+		return ErrMaxSinks
+	}
+	changed := len(newSinks) != len(sc.sinks) || len(newClusterSinks) != len(sc.clusterSinks)
+	for k, s := range newSinks {
+		existing, ok := sc.sinks[k]
+		if !ok || !v1alpha1.SinkSpecEqual(existing.Spec, s.Spec) {
+			changed = true
+		}
+	}
+	for k, cs := range newClusterSinks {
+		existing, ok := sc.clusterSinks[k]
+		if !ok || !v1alpha1.SinkSpecEqual(existing.Spec, cs.Spec) {
+			changed = true
+		}
+	}
+	sc.sinks = newSinks
+	sc.clusterSinks = newClusterSinks
+	sc.draining = make(map[string]time.Time)
+	sc.mu.Unlock()
+	sc.notify(changed)
+	return nil
 }
 
-type sink struct {
-	Addr      string `json:"addr"`
-	Namespace string `json:"namespace,omitempty"`
-	TLS       *tls   `json:"tls,omitempty"`
-	Name      string `json:"name,omitempty"`
+// ConfigDiff describes the changes made by Apply. Sinks are identified as
+// namespace/name and cluster sinks by name, each list sorted.
+type ConfigDiff struct {
+	Added   []string
+	Updated []string
+	Removed []string
+
+	ClusterAdded   []string
+	ClusterUpdated []string
+	ClusterRemoved []string
 }
 
-type tls struct {
-	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+// Empty reports whether Apply made no changes.
+func (d ConfigDiff) Empty() bool {
+	return len(d.Added)+len(d.Updated)+len(d.Removed)+
+		len(d.ClusterAdded)+len(d.ClusterUpdated)+len(d.ClusterRemoved) == 0
 }
 
-func buildHTTPConfig(namespace, URL string, isCluster bool) string {
-	url, err := url.Parse(URL)
+// Apply makes the tracked sinks and cluster sinks match the desired ones and
+// returns what changed. Sinks whose spec is unchanged, as determined by
+// v1alpha1.SinkSpecEqual, are left as they are and not reported. ErrMaxSinks
+// is returned, and nothing changed, when more are desired than the limit set
+// with WithMaxSinks.
+func (sc *Config) Apply(desired []*v1alpha1.LogSink, desiredCluster []*v1alpha1.ClusterLogSink) (ConfigDiff, error) {
+	sc.mu.Lock()
+	diff, err := sc.apply(desired, desiredCluster)
+	sc.mu.Unlock()
 	if err != nil {
-		return ""
+		return ConfigDiff{}, err
 	}
+	sc.notify(!diff.Empty())
+	return diff, nil
+}
 
-	var port string
-	if url.Port() != "" {
-		port = url.Port()
+// ApplyAndRender is like Apply but also returns the config rendered by String
+// after applying the changes, without releasing the lock in between so no
+// other change can be rendered along with them.
+func (sc *Config) ApplyAndRender(desired []*v1alpha1.LogSink, desiredCluster []*v1alpha1.ClusterLogSink) (ConfigDiff, string, error) {
+	sc.mu.Lock()
+	diff, err := sc.apply(desired, desiredCluster)
+	if err != nil {
+		sc.mu.Unlock()
+		return ConfigDiff{}, "", err
 	}
+	config := sc.renderString()
+	sc.mu.Unlock()
+	sc.notify(!diff.Empty())
+	return diff, config, nil
+}
 
-	if port == "" && url.Scheme == "https" {
-		port = "443"
+func (sc *Config) apply(desired []*v1alpha1.LogSink, desiredCluster []*v1alpha1.ClusterLogSink) (ConfigDiff, error) {
+	keys := make(map[string]bool, len(desired))
+	for _, s := range desired {
+		keys[key(s)] = true
 	}
-
-	if port == "" && url.Scheme == "http" {
-		port = "80"
+	clusterKeys := make(map[string]bool, len(desiredCluster))
+	for _, cs := range desiredCluster {
+		clusterKeys[clusterKey(cs)] = true
+	}
+	if sc.overMaxSinks(len(keys) + len(clusterKeys)) {
+		return ConfigDiff{}, ErrMaxSinks
 	}
 
-	var extras string
-	if url.Scheme == "https" {
-		extras = "tls On"
+	var diff ConfigDiff
+	keep := make(map[string]bool, len(desired))
+	for _, s := range desired {
+		k := key(s)
+		keep[k] = true
+		delete(sc.draining, k)
+		existing, ok := sc.sinks[k]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, s.Namespace+"/"+s.Name)
+		case !v1alpha1.SinkSpecEqual(existing.Spec, s.Spec):
+			diff.Updated = append(diff.Updated, s.Namespace+"/"+s.Name)
+		default:
+			continue
+		}
+		sc.sinks[k] = s.DeepCopy()
+	}
+	for k, s := range sc.sinks {
+		if !keep[k] {
+			diff.Removed = append(diff.Removed, s.Namespace+"/"+s.Name)
+			delete(sc.sinks, k)
+			delete(sc.draining, k)
+		}
 	}
 
-	match := fmt.Sprintf("*_%s_*", namespace)
-	if isCluster {
-		match = "*"
+	keep = make(map[string]bool, len(desiredCluster))
+	for _, cs := range desiredCluster {
+		k := clusterKey(cs)
+		keep[k] = true
+		existing, ok := sc.clusterSinks[k]
+		switch {
+		case !ok:
+			diff.ClusterAdded = append(diff.ClusterAdded, cs.Name)
+		case !v1alpha1.SinkSpecEqual(existing.Spec, cs.Spec):
+			diff.ClusterUpdated = append(diff.ClusterUpdated, cs.Name)
+		default:
+			continue
+		}
+		sc.clusterSinks[k] = cs.DeepCopy()
+	}
+	for k, cs := range sc.clusterSinks {
+		if !keep[k] {
+			diff.ClusterRemoved = append(diff.ClusterRemoved, cs.Name)
+			delete(sc.clusterSinks, k)
+		}
 	}
 
-	path := url.Path
-	if path == "" {
-		path = "/"
+	for _, names := range [][]string{
+		diff.Added, diff.Updated, diff.Removed,
+		diff.ClusterAdded, diff.ClusterUpdated, diff.ClusterRemoved,
+	} {
+		sort.Strings(names)
 	}
 
-	return fmt.Sprintf(
-		httpOutputConfig,
-		match,
-		url.Hostname(),
-		port,
-		path,
-		extras,
-	)
+	return diff, nil
 }
 
-func canonicalNamespace(ns string) string {
-	if ns == "" {
-		return "default"
-	}
-	return ns
+func (sc *Config) String() string {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.renderString()
+}
+
+// renderString renders the config for String, recording the render error.
+func (sc *Config) renderString() string {
+	config, err := sc.render()
+	sc.lastRenderErr = err
+	config = sc.applyKeyCase(config)
+	formatted, err := sc.applyFormat(config)
+	if err != nil {
+		log.Printf("unable to format config, rendering the classic format: %s", err)
+		return config
+	}
+	return formatted
+}
+
+// Hash returns the hex encoded SHA-256 of the config rendered by String and
+// the versions set with SetSinkVersion for tracked sinks, so a reloader can
+// detect changes the config does not reflect, such as a rotated certificate.
+func (sc *Config) Hash() string {
+	// The config and the versions are read under a single lock so the hash
+	// does not mix them from before and after a concurrent change.
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	config := sc.renderString()
+	all := sc.allSinks()
+	clusterNames := make(map[string]bool, len(sc.clusterSinks))
+	for _, cs := range sc.clusterSinks {
+		clusterNames[cs.Name] = true
+	}
+	keys := make([]string, 0, len(sc.versions))
+	for k := range sc.versions {
+		parts := strings.SplitN(k, "|", 2)
+		if _, ok := all[k]; ok || parts[0] == "" && clusterNames[parts[1]] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	h.Write([]byte(config))
+	for _, k := range keys {
+		fmt.Fprintf(h, "\n%s=%s", k, sc.versions[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SetSinkVersion associates a version with the sink identified by namespace
+// and name that changes the Hash but not the config, e.g. the resource
+// version of a secret the sink references. Use an empty namespace for
+// cluster sinks. The version is kept until it is cleared with
+// ClearSinkVersion.
+func (sc *Config) SetSinkVersion(namespace, name, version string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.versions[fmt.Sprintf("%s|%s", namespace, name)] = version
+}
+
+// ClearSinkVersion removes a version set with SetSinkVersion.
+func (sc *Config) ClearSinkVersion(namespace, name string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.versions, fmt.Sprintf("%s|%s", namespace, name))
+}
+
+// IsEmpty reports whether String would render the null config because there
+// are no enabled sinks.
+func (sc *Config) IsEmpty() bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.enabledSinkCount() == 0
+}
+
+// RenderChecked renders the config like String but returns an error if a sink
+// failed to render or if the rendered config exceeds the limit set with
+// WithMaxBytes.
+func (sc *Config) RenderChecked() (string, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	config, err := sc.render()
+	sc.lastRenderErr = err
+	config, formatErr := sc.applyFormat(sc.applyKeyCase(config))
+	if formatErr != nil {
+		return "", formatErr
+	}
+	if err != nil {
+		return config, err
+	}
+
+	if sc.maxBytes > 0 && len(config) > sc.maxBytes {
+		return config, fmt.Errorf(
+			"rendered config is %d bytes which exceeds the limit of %d bytes with %d sinks",
+			len(config),
+			sc.maxBytes,
+			len(sc.sinks)+len(sc.clusterSinks),
+		)
+	}
+	return config, nil
+}
+
+// ErrRenderTimeout is returned by RenderWithTimeout when rendering takes
+// longer than the timeout.
+var ErrRenderTimeout = errors.New("timed out rendering config")
+
+// RenderWithTimeout renders the config like RenderChecked, returning
+// ErrRenderTimeout if rendering takes longer than d, e.g. because a renderer
+// registered with WithOutputRenderer blocks. It renders a copy of the Config
+// taken when it is called, so the Config is not locked while rendering and a
+// render that never finishes does not block it.
+func (sc *Config) RenderWithTimeout(d time.Duration) (string, error) {
+	snapshot := sc.snapshot()
+
+	type result struct {
+		config string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		config, err := snapshot.RenderChecked()
+		done <- result{config: config, err: err}
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.config, r.err
+	case <-timer.C:
+		return "", ErrRenderTimeout
+	}
+}
+
+// RenderWithSecrets renders the config like RenderChecked, resolving the
+// secrets referenced by sinks from secrets, keyed by SecretKey, instead of
+// the values set with SetSecret. The values are only used for this render
+// and are not kept by the Config.
+func (sc *Config) RenderWithSecrets(secrets map[string]string) (string, error) {
+	snapshot := sc.snapshot()
+	snapshot.secrets = make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		snapshot.secrets[k] = v
+	}
+	return snapshot.RenderChecked()
+}
+
+// snapshot returns a copy of the Config with the same options and the
+// sinks, secrets and overrides tracked when it is called.
+func (sc *Config) snapshot() *Config {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	c := NewConfig(sc.statsAddr, sc.opts...)
+	for k, s := range sc.sinks {
+		c.sinks[k] = s
+	}
+	for k, s := range sc.clusterSinks {
+		c.clusterSinks[k] = s
+	}
+	for k, s := range sc.templatedSinks {
+		c.templatedSinks[k] = s
+	}
+	for k, v := range sc.namespaces {
+		c.namespaces[k] = v
+	}
+	for k, v := range sc.retryLimits {
+		c.retryLimits[k] = v
+	}
+	for k, v := range sc.secrets {
+		c.secrets[k] = v
+	}
+	return c
+}
+
+// SetDynamicRetryLimit overrides the retry limit rendered for the LogSink
+// identified by namespace and name without modifying the sink itself. The
+// limit should be positive. The override is kept until it is cleared with
+// ClearDynamicRetryLimit.
+func (sc *Config) SetDynamicRetryLimit(namespace, name string, limit int) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.retryLimits[fmt.Sprintf("%s|%s", namespace, name)] = limit
+}
+
+// ClearDynamicRetryLimit removes a retry limit override set with
+// SetDynamicRetryLimit.
+func (sc *Config) ClearDynamicRetryLimit(namespace, name string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.retryLimits, fmt.Sprintf("%s|%s", namespace, name))
+}
+
+// SetSecret records the resolved value of the secret ref in namespace. Use
+// an empty namespace for secrets referenced by cluster sinks. Sinks
+// referencing a secret that has not been set fail to render with an
+// ErrUnresolvedSecret.
+func (sc *Config) SetSecret(namespace string, ref v1alpha1.SecretRef, value string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.secrets[SecretKey(namespace, ref)] = value
+}
+
+// ClearSecret removes a value set with SetSecret.
+func (sc *Config) ClearSecret(namespace string, ref v1alpha1.SecretRef) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.secrets, SecretKey(namespace, ref))
+}
+
+// ErrUnresolvedSecret is returned when rendering a sink that references a
+// secret whose value has not been set with SetSecret. The sink is left out
+// of the config until it is resolved.
+type ErrUnresolvedSecret struct {
+	// Namespace is empty for cluster sinks.
+	Namespace string
+	Name      string
+	Ref       v1alpha1.SecretRef
+}
+
+func (e *ErrUnresolvedSecret) Error() string {
+	if e.Namespace == "" {
+		return fmt.Sprintf("cluster sink %s references unresolved secret %s/%s", e.Name, e.Ref.Name, e.Ref.Key)
+	}
+	return fmt.Sprintf("sink %s/%s references unresolved secret %s/%s", e.Namespace, e.Name, e.Ref.Name, e.Ref.Key)
+}
+
+// LastRenderError returns the error encountered by the most recent call to
+// String, or nil if it rendered every sink successfully.
+func (sc *Config) LastRenderError() error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.lastRenderErr
+}
+
+// render builds the config for every tracked sink. Sinks that fail to render
+// are left out of the config and the first failure is returned alongside it.
+func (sc *Config) render() (string, error) {
+	byType, err := sc.renderByType()
+	var config string
+	for _, t := range sc.outputTypes() {
+		config += byType[t]
+	}
+	return config, err
+}
+
+// OutputTypes lists the keys of the map returned by StringByType in the
+// order they are rendered by String. The source type holds the tail inputs
+// of cluster sinks with a TailSource. The isolate type holds the filters
+// copying the records of sinks filtering them to tags of their own. The
+// parser type holds the filters rendered for sinks with a Parser, ahead of
+// every output. The sample, severity, redact and truncate types hold the
+// filters rendered for sinks with a sample rate, minimum severity, redacted
+// keys or stripped Kubernetes metadata, or maximum record size. Types
+// registered with WithOutputRenderer are rendered after them.
+var OutputTypes = []string{"null", "source", "isolate", "parser", "syslog", "webhook", "gelf", "otlp", "datadog", "azure", "sample", "severity", "redact", "truncate"}
+
+// renderByType renders the config of each output type, leaving out types
+// without any sinks. The null output is only rendered when there are no
+// enabled sinks, unless suppressed with WithSuppressNullConfig.
+func (sc *Config) renderByType() (map[string]string, error) {
+	health, err := sc.healthConfig()
+	if sc.enabledSinkCount() == 0 {
+		byType := make(map[string]string)
+		if !sc.suppressNullConfig {
+			byType["null"] = sc.nullOutputConfig()
+		}
+		if health != "" {
+			byType[healthType] = health
+		}
+		return byType, err
+	}
+
+	syslog, syslogErr := sc.syslogConfig()
+	if err == nil {
+		err = syslogErr
+	}
+	byType := map[string]string{
+		"syslog":   syslog,
+		"sample":   sc.sampleConfig(),
+		"severity": sc.severityConfig(),
+		"redact":   sc.redactConfig(),
+		"truncate": sc.truncateConfig(),
+		"parser":   sc.parserConfig(),
+		"source":   sc.sourceConfig(),
+		"isolate":  sc.isolateConfig(),
+		healthType: health,
+	}
+	types := make([]string, 0, len(sc.renderers))
+	for t := range sc.renderers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		c, rendererErr := sc.rendererConfig(t)
+		if err == nil {
+			err = rendererErr
+		}
+		byType[t] = c
+	}
+	catchAll, catchAllErr := sc.catchAllConfig()
+	if err == nil {
+		err = catchAllErr
+	}
+	byType[catchAllType] = catchAll
+	for t, c := range byType {
+		if c == "" {
+			delete(byType, t)
+		}
+	}
+
+	return byType, err
+}
+
+// StringByType renders the config like String but split by output type, so
+// each type can be written to its own file. Concatenating the values in the
+// order of OutputTypes, followed by any other registered types in sorted
+// order, and the catch-all outputs keyed by "catchall", gives the output of
+// String.
+func (sc *Config) StringByType() map[string]string {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	byType, err := sc.renderByType()
+	sc.lastRenderErr = err
+	for t, c := range byType {
+		byType[t] = sc.applyKeyCase(c)
+	}
+	return byType
+}
+
+// AllNamespaces is returned by CoveredNamespaces when a cluster sink forwards
+// logs from every namespace.
+const AllNamespaces = "*"
+
+// CoveredNamespaces returns the namespaces at least one enabled sink forwards
+// logs from, without duplicates and sorted. It includes AllNamespaces when a
+// cluster sink is not limited to some namespaces.
+func (sc *Config) CoveredNamespaces() []string {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	covered := make(map[string]bool)
+	for _, s := range sc.allSinks() {
+		if !s.Spec.Disabled {
+			covered[s.Namespace] = true
+		}
+	}
+	for _, s := range sc.clusterSinks {
+		if s.Spec.Disabled {
+			continue
+		}
+		if len(s.Spec.IncludeNamespaces) == 0 || s.Spec.CatchAll {
+			covered[AllNamespaces] = true
+			continue
+		}
+		for _, ns := range s.Spec.IncludeNamespaces {
+			covered[ns] = true
+		}
+	}
+
+	namespaces := make([]string, 0, len(covered))
+	for ns := range covered {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// Destination is an address a sink sends records to.
+type Destination struct {
+	Host string
+	Port int
+	// Protocol is one of tcp, udp or tls.
+	Protocol string
+}
+
+// Destinations returns the addresses every enabled sink sends records to,
+// without duplicates and sorted by host, port and protocol. Sinks of types
+// registered with WithOutputRenderer and webhook sinks with an invalid URL
+// are left out.
+func (sc *Config) Destinations() []Destination {
+	sc.mu.Lock()
+	specs := make([]v1alpha1.SinkSpec, 0, len(sc.sinks)+len(sc.clusterSinks))
+	for _, s := range sc.allSinks() {
+		specs = append(specs, s.Spec)
+	}
+	for _, s := range sc.clusterSinks {
+		specs = append(specs, s.Spec)
+		specs = append(specs, routeSpecs(s.Spec)...)
+	}
+	sc.mu.Unlock()
+
+	seen := make(map[Destination]bool)
+	var destinations []Destination
+	for _, spec := range specs {
+		if spec.Disabled {
+			continue
+		}
+		d, ok := sc.destinationOf(spec)
+		if !ok || seen[d] {
+			continue
+		}
+		seen[d] = true
+		destinations = append(destinations, d)
+	}
+
+	sort.Slice(destinations, func(i, j int) bool {
+		a, b := destinations[i], destinations[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Protocol < b.Protocol
+	})
+	return destinations
+}
+
+// Summary returns a one line summary of the enabled sinks for logging, with
+// the number of sinks of each type, the number of cluster sinks and the
+// destinations, e.g. 3 syslog, 1 webhook, 2 cluster sinks -> [collector:514].
+// Unlike String it does not render the config.
+func (sc *Config) Summary() string {
+	sc.mu.RLock()
+	counts := make(map[string]int)
+	for _, s := range sc.allSinks() {
+		if !s.Spec.Disabled {
+			counts[s.Spec.Type]++
+		}
+	}
+	var clusterCount int
+	for _, s := range sc.clusterSinks {
+		if !s.Spec.Disabled {
+			clusterCount++
+		}
+	}
+	sc.mu.RUnlock()
+
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	parts := make([]string, 0, len(types)+1)
+	for _, t := range types {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[t], t))
+	}
+	if clusterCount == 1 {
+		parts = append(parts, "1 cluster sink")
+	} else {
+		parts = append(parts, fmt.Sprintf("%d cluster sinks", clusterCount))
+	}
+
+	var addrs []string
+	for _, d := range sc.Destinations() {
+		addrs = append(addrs, net.JoinHostPort(d.Host, strconv.Itoa(d.Port)))
+	}
+	return fmt.Sprintf("%s -> [%s]", strings.Join(parts, ", "), strings.Join(addrs, ", "))
+}
+
+// disallowedHost returns the destination host of the spec and true if it is
+// not allowed by WithAllowedHosts.
+func (sc *Config) disallowedHost(spec v1alpha1.SinkSpec) (string, bool) {
+	if len(sc.allowedHosts) == 0 {
+		return "", false
+	}
+	d, ok := sc.destinationOf(spec)
+	if !ok {
+		return "", false
+	}
+	for _, allowed := range sc.allowedHosts {
+		if d.Host == allowed || strings.HasPrefix(allowed, ".") && strings.HasSuffix(d.Host, allowed) {
+			return "", false
+		}
+	}
+	return d.Host, true
+}
+
+func (sc *Config) destinationOf(spec v1alpha1.SinkSpec) (Destination, bool) {
+	protocol := func(tls bool) string {
+		if tls {
+			return "tls"
+		}
+		return "tcp"
+	}
+
+	switch spec.Type {
+	case "syslog":
+		// A sink with an unresolved CA secret still uses TLS.
+		t, err := sc.syslogTLS("", "", spec.SyslogSpec)
+		d := Destination{
+			Host:     normalizeHost(spec.Host),
+			Port:     spec.Port,
+			Protocol: protocol(t != nil || err != nil),
+		}
+		if spec.Transport == "udp" {
+			d.Protocol = "udp"
+		}
+		return d, true
+	case "webhook":
+		u, err := url.Parse(spec.URL)
+		if err != nil {
+			return Destination{}, false
+		}
+		port, err := strconv.Atoi(urlPort(u))
+		if err != nil {
+			return Destination{}, false
+		}
+		return Destination{
+			Host:     normalizeHost(u.Hostname()),
+			Port:     port,
+			Protocol: protocol(u.Scheme == "https" || spec.EnableTLS),
+		}, true
+	case "gelf":
+		mode := spec.Mode
+		if mode == "" {
+			mode = "udp"
+		}
+		return Destination{
+			Host:     normalizeHost(spec.Host),
+			Port:     spec.Port,
+			Protocol: mode,
+		}, true
+	case "otlp":
+		return Destination{
+			Host:     normalizeHost(spec.Host),
+			Port:     spec.Port,
+			Protocol: protocol(spec.EnableTLS),
+		}, true
+	case "datadog":
+		return Destination{
+			Host:     datadogHost(spec.DatadogSpec),
+			Port:     443,
+			Protocol: "tls",
+		}, true
+	case "azure":
+		return Destination{
+			Host:     azureHost(spec.AzureSpec),
+			Port:     443,
+			Protocol: "tls",
+		}, true
+	}
+	return Destination{}, false
+}
+
+// Validate validates the spec of every tracked sink and returns an error
+// naming the sink for each that is invalid, sends to a host not allowed by
+// WithAllowedHosts or has Matches outside of its namespace, sorted. An error
+// is also returned when more than one cluster sink is a catch-all.
+func (sc *Config) Validate() []error {
+	sc.mu.Lock()
+	specs := make(map[string]v1alpha1.SinkSpec, len(sc.sinks)+len(sc.clusterSinks))
+	disallowed := make(map[string]string)
+	outside := make(map[string][]string)
+	for _, s := range sc.allSinks() {
+		name := fmt.Sprintf("sink %s/%s", s.Namespace, s.Name)
+		specs[name] = s.Spec
+		if host, ok := sc.disallowedHost(s.Spec); ok {
+			disallowed[name] = host
+		}
+		_, outside[name] = sc.namespaceMatches(s)
+	}
+	var catchAll []string
+	for _, s := range sc.clusterSinks {
+		name := fmt.Sprintf("cluster sink %s", s.Name)
+		specs[name] = s.Spec
+		for _, spec := range append([]v1alpha1.SinkSpec{s.Spec}, routeSpecs(s.Spec)...) {
+			if host, ok := sc.disallowedHost(spec); ok {
+				disallowed[name] = host
+				break
+			}
+		}
+		if s.Spec.CatchAll {
+			catchAll = append(catchAll, s.Name)
+		}
+	}
+	sc.mu.Unlock()
+
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := specs[name].Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", name, err))
+		}
+		if host, ok := disallowed[name]; ok {
+			errs = append(errs, fmt.Errorf("%s: host %s is not allowed", name, host))
+		}
+		for _, match := range outside[name] {
+			errs = append(errs, fmt.Errorf("%s: match %s is outside of the namespace", name, match))
+		}
+	}
+	if len(catchAll) > 1 {
+		sort.Strings(catchAll)
+		errs = append(errs, fmt.Errorf(
+			"cluster sinks %s are catch-all sinks, only one is allowed",
+			strings.Join(catchAll, ", "),
+		))
+	}
+	return errs
+}
+
+// Warnings reports tracked sinks that are likely to confuse operators. They
+// do not prevent the config from rendering. A warning is reported for a
+// LogSink that sends to the same destination as a ClusterLogSink which
+// already forwards its namespace, for a LogSink and ClusterLogSink sharing a
+// name, and for enabled sinks sending to a loopback host such as localhost,
+// which is usually a misconfiguration dropping their logs.
+func (sc *Config) Warnings() []string {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	var warnings []string
+	for _, cs := range sc.clusterSinks {
+		if host := destinationHost(cs.Spec); !cs.Spec.Disabled && isLoopback(host) {
+			warnings = append(warnings, fmt.Sprintf(
+				"cluster sink %s sends to loopback host %s",
+				cs.Name, host,
+			))
+		}
+	}
+	for _, s := range sc.allSinks() {
+		if host := destinationHost(s.Spec); !s.Spec.Disabled && isLoopback(host) {
+			warnings = append(warnings, fmt.Sprintf(
+				"sink %s/%s sends to loopback host %s",
+				s.Namespace, s.Name, host,
+			))
+		}
+		for _, cs := range sc.clusterSinks {
+			if s.Name == cs.Name {
+				warnings = append(warnings, fmt.Sprintf(
+					"sink %s/%s has the same name as cluster sink %s",
+					s.Namespace, s.Name, cs.Name,
+				))
+			}
+			if s.Spec.Disabled || cs.Spec.Disabled {
+				continue
+			}
+			if s.Spec.Type == cs.Spec.Type &&
+				destination(s.Spec) == destination(cs.Spec) &&
+				includesNamespace(cs.Spec, s.Namespace) {
+				warnings = append(warnings, fmt.Sprintf(
+					"sink %s/%s is subsumed by cluster sink %s sending to %s",
+					s.Namespace, s.Name, cs.Name, destination(s.Spec),
+				))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// SinkRef identifies a LogSink.
+type SinkRef struct {
+	Namespace string
+	Name      string
+}
+
+// OverlappingSinks returns the groups of enabled LogSinks whose Match
+// patterns overlap so that records matched by one are also forwarded by the
+// others, e.g. sinks without Matches in the same namespace. Sinks are in a
+// group when their patterns overlap with any other sink of the group. Each
+// group is sorted by namespace and name and the groups by their first sink.
+// Cluster sinks are left out as they are expected to overlap.
+func (sc *Config) OverlappingSinks() [][]SinkRef {
+	sc.mu.RLock()
+	var refs []SinkRef
+	var patterns [][]string
+	for _, s := range sc.allSinks() {
+		if !renderable(s.Spec) {
+			continue
+		}
+		matches, _ := sc.namespaceMatches(s)
+		refs = append(refs, SinkRef{Namespace: s.Namespace, Name: s.Name})
+		patterns = append(patterns, matches)
+	}
+	sc.mu.RUnlock()
+
+	// parent links every sink to a sink of its group, the root of which
+	// links to itself.
+	parent := make([]int, len(refs))
+	for i := range parent {
+		parent[i] = i
+	}
+	root := func(i int) int {
+		for parent[i] != i {
+			i = parent[i]
+		}
+		return i
+	}
+	for i := range refs {
+		for j := i + 1; j < len(refs); j++ {
+			// Records come from a single namespace.
+			if refs[i].Namespace != refs[j].Namespace {
+				continue
+			}
+			if patternsOverlap(patterns[i], patterns[j]) {
+				parent[root(j)] = root(i)
+			}
+		}
+	}
+
+	members := make(map[int][]SinkRef)
+	for i, ref := range refs {
+		members[root(i)] = append(members[root(i)], ref)
+	}
+	less := func(a, b SinkRef) bool {
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return sc.less(a.Name, b.Name)
+	}
+	var groups [][]SinkRef
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return less(group[i], group[j]) })
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return less(groups[i][0], groups[j][0]) })
+	return groups
+}
+
+// patternsOverlap reports whether any of the patterns a overlaps with any of
+// the patterns b.
+func patternsOverlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if matchesOverlap(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesOverlap reports whether a tag exists that is matched by both Match
+// patterns. A * is taken to match any sequence of characters but _, which
+// separates the pod, namespace and container in the tags of records. Regex
+// patterns, rendered as Match_Regex, are only considered to overlap when
+// equal.
+func matchesOverlap(a, b string) bool {
+	if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+		return a == b
+	}
+	// overlap[i][j] reports whether a[i:] and b[j:] overlap.
+	overlap := make([][]bool, len(a)+1)
+	for i := range overlap {
+		overlap[i] = make([]bool, len(b)+1)
+	}
+	for i := len(a); i >= 0; i-- {
+		for j := len(b); j >= 0; j-- {
+			switch {
+			case i == len(a) && j == len(b):
+				overlap[i][j] = true
+			case i < len(a) && a[i] == '*':
+				overlap[i][j] = overlap[i+1][j] || (j < len(b) && b[j] != '_' && overlap[i][j+1])
+			case j < len(b) && b[j] == '*':
+				overlap[i][j] = overlap[i][j+1] || (i < len(a) && a[i] != '_' && overlap[i+1][j])
+			case i < len(a) && j < len(b):
+				overlap[i][j] = a[i] == b[j] && overlap[i+1][j+1]
+			}
+		}
+	}
+	return overlap[0][0]
+}
+
+// destination returns where a sink sends its records.
+func destination(spec v1alpha1.SinkSpec) string {
+	switch spec.Type {
+	case "webhook":
+		return spec.URL
+	case "datadog":
+		return datadogHost(spec.DatadogSpec)
+	case "azure":
+		return azureHost(spec.AzureSpec)
+	}
+	return fmt.Sprintf("%s:%d", normalizeHost(spec.Host), spec.Port)
+}
+
+// destinationHost returns the host a sink sends to, the host of the URL of a
+// webhook sink, or an empty string if it has none.
+func destinationHost(spec v1alpha1.SinkSpec) string {
+	switch spec.Type {
+	case "webhook":
+		u, err := url.Parse(spec.URL)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	case "syslog", "gelf", "otlp":
+		return spec.Host
+	}
+	return ""
+}
+
+// isLoopback reports whether host is localhost or a loopback address.
+func isLoopback(host string) bool {
+	host = normalizeHost(host)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// includesNamespace reports whether a cluster sink forwards records from the
+// given namespace.
+func includesNamespace(spec v1alpha1.SinkSpec, namespace string) bool {
+	if len(spec.Matches) > 0 || spec.TailSource != nil {
+		return false
+	}
+	if excludesSystemNamespaces(spec) {
+		for _, ns := range SystemNamespaces {
+			if ns == namespace {
+				return false
+			}
+		}
+		return true
+	}
+	if len(spec.IncludeNamespaces) == 0 || spec.CatchAll {
+		return true
+	}
+	for _, ns := range spec.IncludeNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// enabledSinkCount returns the number of tracked sinks and cluster sinks that
+// are not disabled.
+func (sc *Config) enabledSinkCount() int {
+	var n int
+	for _, s := range sc.allSinks() {
+		if renderable(s.Spec) {
+			n++
+		}
+	}
+	for _, s := range sc.clusterSinks {
+		if renderable(s.Spec) {
+			n++
+		}
+	}
+	return n
+}
+
+// renderable reports whether a sink is rendered. Disabled sinks and syslog
+// sinks without a host, which Validate reports, are left out.
+func renderable(spec v1alpha1.SinkSpec) bool {
+	if spec.Disabled {
+		return false
+	}
+	return spec.Type != "syslog" || spec.Host != ""
+}
+
+// ReproConfig renders a standalone config containing only the sink
+// identified by namespace and name, a dummy input producing records that are
+// routed to it and the null stats output. An empty namespace falls back to
+// looking up a cluster sink by name, the records of which are given the
+// first namespace it includes. An error is returned for sinks that are not
+// rendered, such as disabled sinks.
+func (sc *Config) ReproConfig(namespace, name string) (string, error) {
+	sc.mu.Lock()
+	repro := NewConfig(sc.statsAddr, sc.opts...)
+	// Only the options rendering the sink are kept, not those adding outputs
+	// of their own such as the health output and the sink templates.
+	repro.healthTag, repro.healthURL = "", ""
+	repro.sinkTemplates = nil
+	var spec v1alpha1.SinkSpec
+	s, ok := sc.allSinks()[fmt.Sprintf("%s|%s", namespace, name)]
+	if ok {
+		repro.UpsertSink(s)
+		spec = s.Spec
+	}
+	var cluster *v1alpha1.ClusterLogSink
+	if !ok && namespace == "" {
+		for _, cs := range sc.clusterSinks {
+			if cs.Name == name {
+				repro.UpsertClusterSink(cs)
+				cluster = cs
+				spec = cs.Spec
+				ok = true
+				break
+			}
+		}
+	}
+	sc.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("sink %s/%s not found", namespace, name)
+	}
+	if !renderable(spec) || (spec.Type != "syslog" && repro.renderers[spec.Type] == nil) {
+		return "", fmt.Errorf("sink %s/%s is disabled or cannot be rendered", namespace, name)
+	}
+
+	// The tag fills in the wildcards of the match of the sink so the record
+	// is routed to it whatever the match template. Cluster sinks matching a
+	// regex are given the match of the namespace of the record instead.
+	recordNamespace := namespace
+	match := ""
+	if cluster != nil {
+		if len(cluster.Spec.IncludeNamespaces) > 0 && !cluster.Spec.CatchAll {
+			recordNamespace = cluster.Spec.IncludeNamespaces[0]
+		}
+		match = repro.clusterMatches(cluster.Spec)[0]
+	}
+	recordNamespace = canonicalNamespace(recordNamespace)
+	if match == "" || strings.HasPrefix(match, "^") {
+		match = repro.namespaceMatch(recordNamespace)
+	}
+	tag := strings.Replace(match, "*", "repro", -1)
+
+	record, err := json.Marshal(map[string]interface{}{
+		"log": "reproduction record",
+		"kubernetes": map[string]string{
+			"namespace_name": recordNamespace,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	repro.mu.Lock()
+	defer repro.mu.Unlock()
+	config, err := repro.render()
+	if err != nil {
+		return "", err
+	}
+	// The preamble is part of the config before it is formatted, so it is
+	// converted along with the sink.
+	config = fmt.Sprintf(dummyInputConfig, tag, record) +
+		repro.nullOutputConfig() +
+		config
+	return repro.applyFormat(repro.applyKeyCase(config))
+}
+
+func buildOTLPConfig(match string, spec v1alpha1.SinkSpec) string {
+	uri := spec.URI
+	if uri == "" {
+		uri = "/v1/logs"
+	}
+
+	config := fmt.Sprintf(otlpOutputConfig, MatchDirective(match), spec.Host, spec.Port, uri)
+	if spec.EnableTLS {
+		config += "    tls On\n"
+		if spec.InsecureSkipVerify != nil && *spec.InsecureSkipVerify {
+			config += "    tls.verify Off\n"
+		}
+	}
+
+	names := make([]string, 0, len(spec.Headers))
+	for name := range spec.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		config += fmt.Sprintf("    Header %s %s\n", name, spec.Headers[name])
+	}
+
+	if spec.Workers > 0 {
+		config += fmt.Sprintf("    workers %d\n", spec.Workers)
+	}
+	if spec.InfiniteRetries {
+		config += fmt.Sprintf("    %s\n", infiniteRetriesDirective)
+	}
+	for _, d := range sizeDirectives(spec) {
+		config += fmt.Sprintf("    %s\n", d)
+	}
+
+	return config
+}
+
+func buildDatadogConfig(match string, spec v1alpha1.SinkSpec) string {
+	config := fmt.Sprintf(datadogOutputConfig, MatchDirective(match), datadogHost(spec.DatadogSpec), spec.APIKey)
+	if spec.Service != "" {
+		config += fmt.Sprintf("    dd_service %s\n", spec.Service)
+	}
+	if spec.Source != "" {
+		config += fmt.Sprintf("    dd_source %s\n", spec.Source)
+	}
+	if spec.Workers > 0 {
+		config += fmt.Sprintf("    workers %d\n", spec.Workers)
+	}
+	if spec.InfiniteRetries {
+		config += fmt.Sprintf("    %s\n", infiniteRetriesDirective)
+	}
+	for _, d := range sizeDirectives(spec) {
+		config += fmt.Sprintf("    %s\n", d)
+	}
+
+	return config
+}
+
+// datadogHost returns the log intake host of the Datadog site.
+func datadogHost(spec v1alpha1.DatadogSpec) string {
+	site := spec.Site
+	if site == "" {
+		site = "datadoghq.com"
+	}
+	return "http-intake.logs." + site
+}
+
+func buildAzureConfig(match string, spec v1alpha1.SinkSpec) string {
+	config := fmt.Sprintf(azureOutputConfig, MatchDirective(match), spec.CustomerID, spec.SharedKey)
+	if spec.LogType != "" {
+		config += fmt.Sprintf("    Log_Type %s\n", spec.LogType)
+	}
+	if spec.Workers > 0 {
+		config += fmt.Sprintf("    workers %d\n", spec.Workers)
+	}
+	if spec.InfiniteRetries {
+		config += fmt.Sprintf("    %s\n", infiniteRetriesDirective)
+	}
+	for _, d := range sizeDirectives(spec) {
+		config += fmt.Sprintf("    %s\n", d)
+	}
+
+	return config
+}
+
+// azureHost returns the data collector host of the Log Analytics workspace.
+func azureHost(spec v1alpha1.AzureSpec) string {
+	return spec.CustomerID + ".ods.opinsights.azure.com"
+}
+
+// sampleConfig renders a sampling filter for every sink with a sample rate
+// greater than 1.
+func (sc *Config) sampleConfig() string {
+	return sc.eachSinkConfig(sampleFilter)
+}
+
+func sampleFilter(match string, spec v1alpha1.SinkSpec) string {
+	if spec.SampleRate <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(sampleFilterConfig, MatchDirective(match), spec.SampleRate)
+}
+
+// severityConfig renders a filter dropping records below the minimum
+// severity of every sink that sets one. No filter is rendered for sinks
+// accepting every severity.
+func (sc *Config) severityConfig() string {
+	return sc.eachSinkConfig(severityFilter)
+}
+
+func severityFilter(match string, spec v1alpha1.SinkSpec) string {
+	below := severitiesBelow(spec.MinSeverity)
+	if len(below) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(severityFilterConfig, MatchDirective(match), strings.Join(below, "|"))
+}
+
+// severitiesBelow returns the Severities less severe than min, none if min
+// is not one of them.
+func severitiesBelow(min string) []string {
+	for i, s := range v1alpha1.Severities {
+		if s == min {
+			return v1alpha1.Severities[i+1:]
+		}
+	}
+	return nil
+}
+
+// redactConfig renders a modify filter for every sink redacting keys or
+// stripping Kubernetes metadata. The patterns are sorted and deduplicated so
+// the filter does not depend on their order in the spec.
+func (sc *Config) redactConfig() string {
+	return sc.eachSinkConfig(redactFilter)
+}
+
+func redactFilter(match string, spec v1alpha1.SinkSpec) string {
+	if len(spec.Redact) == 0 && !spec.StripKubernetesMetadata {
+		return ""
+	}
+	patterns := append([]string{}, spec.Redact...)
+	sort.Strings(patterns)
+	config := fmt.Sprintf(redactFilterConfig, MatchDirective(match))
+	if spec.StripKubernetesMetadata {
+		config += "    Remove kubernetes\n"
+	}
+	for i, p := range patterns {
+		if i > 0 && p == patterns[i-1] {
+			continue
+		}
+		config += fmt.Sprintf("    Remove_regex %s\n", p)
+	}
+	return config
+}
+
+// truncateConfig renders a filter truncating the log key of records for
+// every sink with a maximum record size.
+func (sc *Config) truncateConfig() string {
+	return sc.eachSinkConfig(truncateFilter)
+}
+
+func truncateFilter(match string, spec v1alpha1.SinkSpec) string {
+	if spec.MaxRecordBytes <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(truncateFilterConfig, MatchDirective(match), spec.MaxRecordBytes)
+}
+
+// sourceConfig renders a tail input for every enabled cluster sink with a
+// TailSource, ordered by name. The input reads in chunks of the ChunkSize of
+// the sink, and is paused rather than dropping records when the sink blocks
+// on backpressure.
+func (sc *Config) sourceConfig() string {
+	keys := make([]string, 0, len(sc.clusterSinks))
+	for k, s := range sc.clusterSinks {
+		if !s.Spec.Disabled && s.Spec.TailSource != nil {
+			keys = append(keys, k)
+		}
+	}
+	sc.sortKeys(keys)
+
+	var config string
+	for _, k := range keys {
+		config += sourceInputConfig(sc.clusterSinks[k].Spec)
+	}
+	return config
+}
+
+// sourceInputConfig renders the tail input of the TailSource of spec, if it
+// has one.
+func sourceInputConfig(spec v1alpha1.SinkSpec) string {
+	if spec.TailSource == nil {
+		return ""
+	}
+	config := fmt.Sprintf(tailInputConfig, spec.TailSource.Path, spec.TailSource.Tag)
+	if spec.ChunkSize != "" {
+		config += fmt.Sprintf("    Buffer_Chunk_Size %s\n", spec.ChunkSize)
+	}
+	if spec.OnBackpressure == v1alpha1.BackpressureBlock {
+		config += fmt.Sprintf("    %s\n", pauseOnOverlimitDirective)
+	}
+	return config
+}
+
+// isolateConfig renders the filters copying the records of every isolated
+// sink to its tag, ordered like eachSinkConfig.
+func (sc *Config) isolateConfig() string {
+	var config string
+	for _, s := range sc.orderedSinks() {
+		config += sc.isolateSinkConfig(s)
+	}
+	return config
+}
+
+// isolateSinkConfig renders the filters copying the records of a sink to its
+// tag, if it is isolated.
+func (sc *Config) isolateSinkConfig(s *v1alpha1.LogSink) string {
+	if !isolated(s.Spec) {
+		return ""
+	}
+	var config string
+	tag := isolationTag(s.Namespace, s.Name)
+	emitter := strings.Replace(tag, ".", "_", -1)
+	for i, match := range sc.sourceMatches(s) {
+		name := emitter
+		if i > 0 {
+			name = fmt.Sprintf("%s_%d", emitter, i)
+		}
+		config += fmt.Sprintf(isolateFilterConfig, MatchDirective(match), tag, name)
+	}
+	return config
+}
+
+// parserConfig renders a parser filter for every sink with a Parser.
+func (sc *Config) parserConfig() string {
+	return sc.eachSinkConfig(parserFilter)
+}
+
+func parserFilter(match string, spec v1alpha1.SinkSpec) string {
+	if spec.Parser == "" {
+		return ""
+	}
+	return fmt.Sprintf(parserFilterConfig, MatchDirective(match), spec.Parser)
+}
+
+// eachSinkConfig renders the config returned by render for every enabled
+// sink and each of its filterMatches, ordered like orderedSinks. Sinks for
+// which render returns an empty string are left out.
+func (sc *Config) eachSinkConfig(render func(match string, spec v1alpha1.SinkSpec) string) string {
+	var config string
+	for _, s := range sc.orderedSinks() {
+		config += sc.sinkFilterConfig(s, render)
+	}
+	return config
+}
+
+// sinkFilterConfig renders the config returned by render for each of the
+// filterMatches of a sink.
+func (sc *Config) sinkFilterConfig(s *v1alpha1.LogSink, render func(match string, spec v1alpha1.SinkSpec) string) string {
+	var config string
+	for _, match := range sc.filterMatches(s) {
+		config += render(match, s.Spec)
+	}
+	return config
+}
+
+// orderedSinks returns the enabled sinks ordered by namespace and name,
+// followed by the enabled cluster sinks, as LogSinks without a namespace,
+// ordered with sortClusterKeys.
+func (sc *Config) orderedSinks() []*v1alpha1.LogSink {
+	all := sc.allSinks()
+	keys := make([]string, 0, len(all))
+	for k, s := range all {
+		if !s.Spec.Disabled {
+			keys = append(keys, k)
+		}
+	}
+	sc.sortKeys(keys)
+	sinks := make([]*v1alpha1.LogSink, 0, len(keys)+len(sc.clusterSinks))
+	for _, k := range keys {
+		sinks = append(sinks, all[k])
+	}
+
+	keys = keys[:0]
+	for k, s := range sc.clusterSinks {
+		if !s.Spec.Disabled {
+			keys = append(keys, k)
+		}
+	}
+	sc.sortClusterKeys(keys)
+	for _, k := range keys {
+		sinks = append(sinks, clusterLogSink(sc.clusterSinks[k]))
+	}
+	return sinks
+}
+
+// filterMatches returns the Match patterns of the filters of a sink, being
+// its MatchPatterns along with the tags of its routes if it is an isolated
+// cluster sink with routes. The routes re-emit the records of the sink
+// before its filters apply.
+func (sc *Config) filterMatches(s *v1alpha1.LogSink) []string {
+	matches := sc.MatchPatterns(s)
+	if s.Namespace == "" && isolated(s.Spec) && s.Spec.Type == "webhook" &&
+		(s.Spec.RouteByField != "" || len(s.Spec.Routes) > 0) {
+		matches = append(matches, fmt.Sprintf("route.%s.*", s.Name))
+	}
+	return matches
+}
+
+func buildGELFConfig(match string, spec v1alpha1.SinkSpec) string {
+	mode := spec.Mode
+	if mode == "" {
+		mode = "udp"
+	}
+
+	config := fmt.Sprintf(gelfOutputConfig, MatchDirective(match), spec.Host, spec.Port, mode)
+	if mode == "tls" {
+		config += "    tls On\n"
+		if spec.InsecureSkipVerify != nil && *spec.InsecureSkipVerify {
+			config += "    tls.verify Off\n"
+		}
+	}
+	if spec.Workers > 0 {
+		config += fmt.Sprintf("    workers %d\n", spec.Workers)
+	}
+	if spec.InfiniteRetries {
+		config += fmt.Sprintf("    %s\n", infiniteRetriesDirective)
+	}
+	for _, d := range sizeDirectives(spec) {
+		config += fmt.Sprintf("    %s\n", d)
+	}
+
+	return config
+}
+
+// syslogGroup is the sinks rendered into a single syslog output.
+type syslogGroup struct {
+	sinks        []sink
+	clusterSinks []sink
+	workers      int
+	// isolated groups hold isolated sinks, matched by the tags their
+	// records are copied to.
+	isolated bool
+}
+
+func (sc *Config) syslogConfig() (string, error) {
+	// TLS and non-TLS sinks are rendered into separate outputs, each given
+	// the most workers requested by any of its sinks. Isolated sinks are
+	// rendered into outputs of their own as well.
+	groups := [4]syslogGroup{2: {isolated: true}, 3: {isolated: true}}
+	group := func(t *tls, spec v1alpha1.SinkSpec) *syslogGroup {
+		i := 0
+		if t != nil {
+			i++
+		}
+		if isolated(spec) {
+			i += 2
+		}
+		return &groups[i]
+	}
+	var secretErr error
+	for _, s := range sc.allSinks() {
+		if s.Spec.Type != "syslog" || !renderable(s.Spec) {
+			continue
+		}
+		t, err := sc.syslogTLS(s.Namespace, s.Name, s.Spec.SyslogSpec)
+		if err != nil {
+			if secretErr == nil {
+				secretErr = err
+			}
+			continue
+		}
+		g := group(t, s.Spec)
+		if s.Spec.Workers > g.workers {
+			g.workers = s.Spec.Workers
+		}
+		var matches []string
+		if g.isolated {
+			matches = []string{isolationTag(s.Namespace, s.Name)}
+		} else if len(s.Spec.Matches) > 0 {
+			matches, _ = sc.namespaceMatches(s)
+		}
+
+		g.sinks = append(g.sinks, sink{
+			Addr:           fmt.Sprintf("%s:%d", normalizeHost(s.Spec.Host), s.Spec.Port),
+			Namespace:      canonicalNamespace(s.Namespace),
+			TLS:            t,
+			Name:           s.Name,
+			RetryLimit:     sc.retryLimits[key(s)],
+			Matches:        matches,
+			OnBackpressure: s.Spec.OnBackpressure,
+			BackoffMs:      positive(s.Spec.ReconnectBackoffMs),
+			MaxMs:          positive(s.Spec.ReconnectMaxMs),
+			StructuredData: s.Spec.StructuredData,
+			Transport:      s.Spec.Transport,
+			Format:         s.Spec.SyslogFormat,
+			MessageKey:     s.Spec.MessageKey,
+		})
+	}
+
+	for _, s := range sc.clusterSinks {
+		if s.Spec.Type != "syslog" || !renderable(s.Spec) {
+			continue
+		}
+		t, err := sc.syslogTLS("", s.Name, s.Spec.SyslogSpec)
+		if err != nil {
+			if secretErr == nil {
+				secretErr = err
+			}
+			continue
+		}
+		g := group(t, s.Spec)
+		if s.Spec.Workers > g.workers {
+			g.workers = s.Spec.Workers
+		}
+		namespaces := s.Spec.IncludeNamespaces
+		if s.Spec.CatchAll {
+			namespaces = nil
+		}
+		var exclude []string
+		if excludesSystemNamespaces(s.Spec) {
+			exclude = SystemNamespaces
+		}
+		matches := s.Spec.Matches
+		if s.Spec.TailSource != nil {
+			matches = []string{s.Spec.TailSource.Tag}
+		}
+		if g.isolated {
+			matches = []string{isolationTag("", s.Name)}
+		}
+		if len(matches) > 0 {
+			namespaces, exclude = nil, nil
+		}
+
+		g.clusterSinks = append(g.clusterSinks, sink{
+			Addr:              fmt.Sprintf("%s:%d", normalizeHost(s.Spec.Host), s.Spec.Port),
+			TLS:               t,
+			Name:              s.Name,
+			Namespaces:        namespaces,
+			Matches:           matches,
+			ExcludeNamespaces: exclude,
+			OnBackpressure:    s.Spec.OnBackpressure,
+			BackoffMs:         positive(s.Spec.ReconnectBackoffMs),
+			MaxMs:             positive(s.Spec.ReconnectMaxMs),
+			StructuredData:    s.Spec.StructuredData,
+			Transport:         s.Spec.Transport,
+			Format:            s.Spec.SyslogFormat,
+			MessageKey:        s.Spec.MessageKey,
+			priority:          s.Spec.Priority,
+			catchAll:          s.Spec.CatchAll,
+		})
+	}
+
+	var config string
+	var err error
+	for _, g := range groups {
+		if len(g.sinks)+len(g.clusterSinks) == 0 {
+			continue
+		}
+		output, outputErr := sc.syslogOutput(g)
+		if err == nil {
+			err = outputErr
+		}
+		config += output
+	}
+	if err == nil {
+		err = secretErr
+	}
+	if config == "" {
+		return "", err
+	}
+
+	return sc.withAliases(config, "syslog"), err
+}
+
+// syslogOutput renders a syslog output for the sinks of g.
+func (sc *Config) syslogOutput(g syslogGroup) (string, error) {
+	sinks, clusterSinks := g.sinks, g.clusterSinks
+	if sinks == nil {
+		sinks = []sink{}
+	}
+	if clusterSinks == nil {
+		clusterSinks = []sink{}
+	}
+	sort.Slice(sinks, func(i, j int) bool {
+		if sc.sortByAddr && sinks[i].Addr != sinks[j].Addr {
+			return sinks[i].Addr < sinks[j].Addr
+		}
+		if sc.sortByAddr && sinks[i].Name != sinks[j].Name {
+			return sc.less(sinks[i].Name, sinks[j].Name)
+		}
+		if sinks[i].Namespace != sinks[j].Namespace {
+			return sinks[i].Namespace < sinks[j].Namespace
+		}
+		return sc.less(sinks[i].Name, sinks[j].Name)
+	})
+	// TODO: don't return null config yet. just set to empty json
+	sinksJSON, sinksErr := json.Marshal(sinks)
+	if sinksErr != nil {
+		log.Print("unable to marshal sinks")
+		sinksJSON = []byte("[]")
+	}
+
+	sort.Slice(clusterSinks, func(i, j int) bool {
+		a, b := clusterSinks[i], clusterSinks[j]
+		if a.catchAll != b.catchAll {
+			return b.catchAll
+		}
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		if sc.sortByAddr && a.Addr != b.Addr {
+			return a.Addr < b.Addr
+		}
+		return sc.less(a.Name, b.Name)
+	})
+	clusterSinksJSON, err := json.Marshal(clusterSinks)
+	if err != nil {
+		log.Print("unable to marshal cluster sinks")
+		clusterSinksJSON = []byte("[]")
+	}
+	if sinksErr != nil {
+		err = sinksErr
+	}
+
+	match := "*"
+	switch {
+	case g.isolated:
+		match = isolationTagPrefix + "*"
+	case sc.isolating():
+		match = excludeIsolationTags(match)
+	}
+	config := fmt.Sprintf(`
+[OUTPUT]
+    Name syslog
+    %s
+    StatsAddr %s
+    Sinks %s
+    ClusterSinks %s
+`, MatchDirective(match), sc.statsAddr, sinksJSON, clusterSinksJSON)
+	if sc.sinkComments {
+		comments := make([]string, 0, len(sinks)+len(clusterSinks))
+		for _, s := range sinks {
+			comments = append(comments, sinkComment(s.Namespace, s.Name))
+		}
+		for _, s := range clusterSinks {
+			comments = append(comments, sinkComment("", s.Name))
+		}
+		config = sc.withComments(config, comments...)
+	}
+	if g.workers > 0 {
+		config += fmt.Sprintf("    workers %d\n", g.workers)
+	}
+
+	return config, err
+}
+
+// syslogTLS returns the TLS settings of a syslog sink, or nil if it does not
+// use TLS. Sinks that do not enable TLS themselves use the defaults set with
+// WithDefaultEnableTLS and WithDefaultInsecureSkipVerify unless they opt out
+// with DisableTLS. The InsecureSkipVerify of the sink always wins over the
+// default when it is set. An ErrUnresolvedSecret is returned if the sink
+// uses TLS and its CA secret has not been resolved.
+func (sc *Config) syslogTLS(namespace, name string, spec v1alpha1.SyslogSpec) (*tls, error) {
+	var t *tls
+	if spec.EnableTLS {
+		t = &tls{}
+	} else if sc.defaultEnableTLS && !spec.DisableTLS && spec.Transport != "udp" {
+		t = &tls{
+			InsecureSkipVerify: sc.defaultInsecureSkipVerify,
+		}
+	}
+	if t == nil {
+		return nil, nil
+	}
+	if spec.InsecureSkipVerify != nil {
+		t.InsecureSkipVerify = *spec.InsecureSkipVerify
+	}
+	t.CAFile = spec.CAFile
+	t.CertFile = spec.CertFile
+	t.KeyFile = spec.KeyFile
+	if spec.CASecretRef == nil {
+		return t, nil
+	}
+
+	t.CA = sc.secrets[SecretKey(namespace, *spec.CASecretRef)]
+	if t.CA == "" {
+		return nil, &ErrUnresolvedSecret{
+			Namespace: namespace,
+			Name:      name,
+			Ref:       *spec.CASecretRef,
+		}
+	}
+	return t, nil
+}
+
+type sink struct {
+	Addr       string   `json:"addr"`
+	Namespace  string   `json:"namespace,omitempty"`
+	TLS        *tls     `json:"tls,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	RetryLimit int      `json:"retry_limit,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Matches is only set for sinks with Matches, replacing Namespace,
+	// Namespaces and ExcludeNamespaces.
+	Matches []string `json:"matches,omitempty"`
+	// ExcludeNamespaces is only set for cluster sinks without Namespaces.
+	ExcludeNamespaces []string `json:"exclude_namespaces,omitempty"`
+	OnBackpressure    string   `json:"on_backpressure,omitempty"`
+	BackoffMs         int      `json:"reconnect_backoff_ms,omitempty"`
+	MaxMs             int      `json:"reconnect_max_ms,omitempty"`
+	// StructuredData is marshalled with its keys sorted.
+	StructuredData map[string]string `json:"structured_data,omitempty"`
+	Transport      string            `json:"transport,omitempty"`
+	Format         string            `json:"format,omitempty"`
+	MessageKey     string            `json:"message_key,omitempty"`
+
+	// priority and catchAll order cluster sinks like sortClusterKeys.
+	priority int
+	catchAll bool
+}
+
+// positive returns n, or zero if n is negative so it is omitted from the
+// sink JSON.
+func positive(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+type tls struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+	CA                 string `json:"ca,omitempty"`
+	// CAFile, CertFile and KeyFile are paths, unlike CA which holds the
+	// PEM encoded CA itself.
+	CAFile   string `json:"ca_file,omitempty"`
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+}
+
+func (sc *Config) buildHTTPConfig(
+	match string,
+	spec v1alpha1.SinkSpec,
+	retryLimit int,
+) (string, error) {
+	url, err := url.Parse(spec.URL)
+	if err != nil {
+		return "", err
+	}
+
+	port := urlPort(url)
+
+	var extras []string
+	if url.Scheme == "https" || spec.EnableTLS {
+		extras = append(extras, sc.httpTLSDirective()+" On")
+		if spec.TLSVerify != nil {
+			extras = append(extras, "tls.verify "+onOff(*spec.TLSVerify))
+		}
+		if spec.CA != "" {
+			extras = append(extras, "tls.ca_file "+spec.CA)
+		}
+		if spec.ClientCert != "" {
+			extras = append(extras, "tls.crt_file "+spec.ClientCert)
+		}
+		if spec.ClientKey != "" {
+			extras = append(extras, "tls.key_file "+spec.ClientKey)
+		}
+		if spec.TLSVHost != "" {
+			extras = append(extras, "tls.vhost "+spec.TLSVHost)
+		}
+	}
+	if spec.TimeKey != "" {
+		extras = append(extras, fmt.Sprintf("json_date_key %s", spec.TimeKey))
+	}
+	if spec.DateFormat != "" {
+		extras = append(extras, fmt.Sprintf("json_date_format %s", spec.DateFormat))
+	}
+	if spec.Workers > 0 {
+		extras = append(extras, fmt.Sprintf("workers %d", spec.Workers))
+	}
+	if spec.InfiniteRetries {
+		extras = append(extras, infiniteRetriesDirective)
+	} else if retryLimit > 0 {
+		extras = append(extras, fmt.Sprintf("Retry_Limit %d", retryLimit))
+	}
+	extras = append(extras, sizeDirectives(spec)...)
+	if spec.KeepAlive != nil {
+		extras = append(extras, "net.keepalive "+onOff(*spec.KeepAlive))
+	}
+	if spec.ConnectTimeoutSeconds > 0 {
+		extras = append(extras, fmt.Sprintf("net.connect_timeout %d", spec.ConnectTimeoutSeconds))
+	}
+	if spec.HTTP2 {
+		extras = append(extras, "http2 On")
+	}
+
+	path := url.Path
+	if path == "" {
+		path = "/"
+	}
+
+	config := fmt.Sprintf(
+		httpOutputConfig,
+		MatchDirective(match),
+		normalizeHost(url.Hostname()),
+	)
+	// The port of a URL with a scheme other than http or https is unknown,
+	// so it is left to the output default rather than rendered empty.
+	if port != "" {
+		config += fmt.Sprintf("    Port %s\n", port)
+	}
+	config += fmt.Sprintf("    URI %s\n", path)
+	for _, e := range extras {
+		config += fmt.Sprintf("    %s\n", e)
+	}
+
+	return config, nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "On"
+	}
+	return "Off"
+}
+
+// buildRoutedHTTPConfig renders the rewrite_tag filters and the http output
+// of a webhook cluster sink with a RouteByField.
+func (sc *Config) buildRoutedHTTPConfig(s *v1alpha1.LogSink, retryLimit int) (string, error) {
+	name, spec := s.Name, s.Spec
+	prefix := fmt.Sprintf("route.%s.", name)
+	accessor := recordAccessor(spec.RouteByField)
+
+	var config string
+	for i, match := range sc.MatchPatterns(s) {
+		config += fmt.Sprintf(
+			rewriteTagFilterConfig,
+			MatchDirective(excludeRouteTags(match)),
+			accessor,
+			prefix,
+			routeEmitter(name, i),
+		)
+	}
+
+	output, err := sc.buildHTTPConfig(prefix+"*", spec, retryLimit)
+	if err != nil {
+		return "", err
+	}
+	return config + output + fmt.Sprintf("    header_tag %s\n", routeTagHeader), nil
+}
+
+// buildRoutesHTTPConfig renders the filters re-emitting the records matching
+// the Routes of a webhook cluster sink, tagged route.<sink name>.<index of
+// the route>, an output for each route and the outputs of the sink for every
+// other record.
+func (sc *Config) buildRoutesHTTPConfig(s *v1alpha1.LogSink, retryLimit int) (string, error) {
+	name, spec := s.Name, s.Spec
+	matches := sc.MatchPatterns(s)
+
+	var config string
+	for i, match := range matches {
+		config += fmt.Sprintf(
+			"\n[FILTER]\n    Name rewrite_tag\n    %s\n",
+			MatchDirective(excludeRouteTags(match)),
+		)
+		for j, r := range spec.Routes {
+			config += fmt.Sprintf(
+				"    Rule %s %s route.%s.%d false\n",
+				recordAccessor(r.Field),
+				r.Pattern,
+				name,
+				j,
+			)
+		}
+		config += fmt.Sprintf("    Emitter_Name %s\n", routeEmitter(name, i))
+	}
+
+	for j, routeSpec := range routeSpecs(spec) {
+		output, err := sc.buildHTTPConfig(fmt.Sprintf("route.%s.%d", name, j), routeSpec, retryLimit)
+		if err != nil {
+			return "", fmt.Errorf("route %d: %s", j, err)
+		}
+		config += output
+	}
+	for _, match := range matches {
+		output, err := sc.buildHTTPConfig(excludeRouteTags(match), spec, retryLimit)
+		if err != nil {
+			return "", err
+		}
+		config += output
+	}
+	return config, nil
+}
+
+// routeSpecs returns the spec of the output of each of the Routes of a
+// spec, being the spec with the URL of the route.
+func routeSpecs(spec v1alpha1.SinkSpec) []v1alpha1.SinkSpec {
+	specs := make([]v1alpha1.SinkSpec, 0, len(spec.Routes))
+	for _, r := range spec.Routes {
+		s := spec
+		s.URL = r.URL
+		s.Routes = nil
+		specs = append(specs, s)
+	}
+	return specs
+}
+
+// excludeRouteTags returns a pattern matching the tags matched by match but
+// those of records re-emitted by a rewrite_tag filter of a routed sink, so
+// they are not routed again.
+func excludeRouteTags(match string) string {
+	switch {
+	case match == "*":
+		return `^(?!route\.).*$`
+	case strings.HasPrefix(match, "^"):
+		return `^(?!route\.)` + match[1:]
+	}
+	return match
+}
+
+// recordAccessor returns the record accessor of a dot separated field path,
+// e.g. $kubernetes['namespace_name'] for kubernetes.namespace_name.
+func recordAccessor(field string) string {
+	fields := strings.Split(field, ".")
+	accessor := "$" + fields[0]
+	for _, f := range fields[1:] {
+		accessor += fmt.Sprintf("['%s']", f)
+	}
+	return accessor
+}
+
+// routeEmitter names the emitter of the i-th rewrite_tag filter of a routed
+// cluster sink.
+func routeEmitter(name string, i int) string {
+	if i > 0 {
+		return fmt.Sprintf("route_%s_%d", name, i)
+	}
+	return "route_" + name
+}
+
+// healthConfig renders the output set with WithHealthOutput, if any.
+func (sc *Config) healthConfig() (string, error) {
+	if sc.healthURL == "" {
+		return "", nil
+	}
+	spec := v1alpha1.SinkSpec{
+		Type: "webhook",
+		WebhookSpec: v1alpha1.WebhookSpec{
+			URL: sc.healthURL,
+		},
+	}
+	config, err := sc.buildHTTPConfig(sc.healthTag, spec, 0)
+	if err != nil {
+		return "", fmt.Errorf("health output: %s", err)
+	}
+	return sc.withAliases(config, healthType), nil
+}
+
+// nullOutputConfig renders the null output, with the match set with
+// WithNullMatch and the alias set with WithStatsAlias.
+func (sc *Config) nullOutputConfig() string {
+	match := sc.nullMatch
+	if match == "" {
+		match = "*"
+	}
+	config := fmt.Sprintf(nullConfig, match, sc.statsAddr)
+	if sc.statsAlias != "" {
+		config += fmt.Sprintf("    Alias %s\n", sc.statsAlias)
+	}
+	return config
+}
+
+// withComments prefixes every output in config with the comments when
+// enabled with WithSinkComments.
+func (sc *Config) withComments(config string, comments ...string) string {
+	if !sc.sinkComments {
+		return config
+	}
+	var prefix string
+	for _, c := range comments {
+		prefix += "# " + c + "\n"
+	}
+	return strings.Replace(config, "\n[OUTPUT]\n", "\n"+prefix+"[OUTPUT]\n", -1)
+}
+
+// withAliases renders an Alias after the first line of every output in
+// config when enabled with WithSinkAliases. The first output is given alias
+// and the following ones alias with their index appended.
+func (sc *Config) withAliases(config, alias string) string {
+	if !sc.sinkAliases {
+		return config
+	}
+	parts := strings.Split(config, "\n[OUTPUT]\n")
+	for i := 1; i < len(parts); i++ {
+		a := alias
+		if i > 1 {
+			a = fmt.Sprintf("%s_%d", alias, i-1)
+		}
+		line := strings.Index(parts[i], "\n") + 1
+		parts[i] = parts[i][:line] + fmt.Sprintf("    Alias %s\n", a) + parts[i][line:]
+	}
+	return strings.Join(parts, "\n[OUTPUT]\n")
+}
+
+// sinkAlias is the alias of the outputs of a sink rendered by withAliases.
+// An empty namespace identifies a cluster sink.
+func sinkAlias(namespace, name string) string {
+	if namespace == "" {
+		return "cluster_" + name
+	}
+	return fmt.Sprintf("ns_%s_%s", namespace, name)
+}
+
+// less orders sink names and keys, numerically where they contain digits
+// when enabled with WithNaturalSort and lexically otherwise.
+func (sc *Config) less(a, b string) bool {
+	if sc.naturalSort {
+		return naturalLess(a, b)
+	}
+	return a < b
+}
+
+// sortKeys sorts sink keys with less.
+func (sc *Config) sortKeys(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		return sc.less(keys[i], keys[j])
+	})
+}
+
+// sortClusterKeys sorts cluster sink keys by descending Priority, with
+// catch-all sinks last and ties sorted with less.
+func (sc *Config) sortClusterKeys(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := sc.clusterSinks[keys[i]].Spec, sc.clusterSinks[keys[j]].Spec
+		if a.CatchAll != b.CatchAll {
+			return b.CatchAll
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return sc.less(keys[i], keys[j])
+	})
+}
+
+// naturalLess compares runs of digits in a and b by their numeric value and
+// everything else lexically. Strings that only differ in leading zeros are
+// ordered lexically so the order is total.
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			if a[i] != b[j] {
+				return a[i] < b[j]
+			}
+			i++
+			j++
+			continue
+		}
+
+		startA, startB := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		numA := strings.TrimLeft(a[startA:i], "0")
+		numB := strings.TrimLeft(b[startB:j], "0")
+		if len(numA) != len(numB) {
+			return len(numA) < len(numB)
+		}
+		if numA != numB {
+			return numA < numB
+		}
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// sinkComment identifies a sink in the comments rendered by withComments. An
+// empty namespace identifies a cluster sink.
+func sinkComment(namespace, name string) string {
+	if namespace == "" {
+		return "cluster sink: " + name
+	}
+	return fmt.Sprintf("sink: %s/%s", namespace, name)
+}
+
+// urlPort returns the port of the URL, defaulting to the port of its scheme.
+func urlPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Port()
+	}
+	switch u.Scheme {
+	case "https":
+		return "443"
+	case "http":
+		return "80"
+	}
+	return ""
+}
+
+func (sc *Config) httpTLSDirective() string {
+	if sc.httpPluginVersion == HTTPPluginV2 {
+		return "tls.on"
+	}
+	return "tls"
+}
+
+func (sc *Config) applyKeyCase(config string) string {
+	if sc.keyCase == LowerCase {
+		return lowerKeys(config)
+	}
+	return config
+}
+
+// lowerKeys lower cases the key of every directive in the rendered config.
+// Directives are the indented lines within a section, the key being
+// everything up to the first space.
+func lowerKeys(config string) string {
+	lines := strings.Split(config, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == line || trimmed == "" {
+			continue
+		}
+		indent := line[:len(line)-len(trimmed)]
+		parts := strings.SplitN(trimmed, " ", 2)
+		parts[0] = strings.ToLower(parts[0])
+		lines[i] = indent + strings.Join(parts, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SystemNamespaces are the namespaces cluster sinks matching every namespace
+// leave out unless they set IncludeSystemNamespaces.
+var SystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// excludesSystemNamespaces reports whether a cluster sink matches every
+// namespace but SystemNamespaces.
+func excludesSystemNamespaces(spec v1alpha1.SinkSpec) bool {
+	return !spec.IncludeSystemNamespaces && (len(spec.IncludeNamespaces) == 0 || spec.CatchAll)
+}
+
+// clusterMatches returns the Match patterns of the outputs rendered for a
+// cluster sink, being the tag of its TailSource if it has one, its Matches
+// if it has any, otherwise one per included namespace or a single one
+// matching every namespace, but SystemNamespaces unless the sink includes
+// them.
+func (sc *Config) clusterMatches(spec v1alpha1.SinkSpec) []string {
+	if spec.TailSource != nil {
+		return []string{spec.TailSource.Tag}
+	}
+	if len(spec.Matches) > 0 {
+		return spec.Matches
+	}
+	if excludesSystemNamespaces(spec) {
+		return []string{sc.excludeSystemNamespacesMatch()}
+	}
+	if len(spec.IncludeNamespaces) == 0 || spec.CatchAll {
+		return []string{"*"}
+	}
+	matches := make([]string, 0, len(spec.IncludeNamespaces))
+	for _, ns := range spec.IncludeNamespaces {
+		matches = append(matches, sc.namespaceMatch(ns))
+	}
+	return matches
+}
+
+// namespaceMatches returns the Match patterns of a namespaced sink, being the
+// Matches within its namespace or the match of the namespace if there are
+// none. Matches that are not within the namespace are returned as outside.
+// A pattern is within the namespace if it contains every literal part of the
+// match of the namespace, e.g. _some-namespace_ for *_some-namespace_*.
+func (sc *Config) namespaceMatches(s *v1alpha1.LogSink) (matches, outside []string) {
+	nsMatch := sc.namespaceMatch(s.Namespace)
+	for _, p := range s.Spec.Matches {
+		within := true
+		for _, part := range strings.Split(nsMatch, "*") {
+			if !strings.Contains(p, part) {
+				within = false
+			}
+		}
+		if within {
+			matches = append(matches, p)
+		} else {
+			outside = append(outside, p)
+		}
+	}
+	if len(matches) == 0 {
+		matches = []string{nsMatch}
+	}
+	return matches, outside
+}
+
+// sourceMatches returns the Match patterns of the records routed to a sink,
+// like MatchPatterns without isolating the sink.
+func (sc *Config) sourceMatches(s *v1alpha1.LogSink) []string {
+	var matches []string
+	if s.Namespace == "" {
+		matches = sc.clusterMatches(s.Spec)
+	} else {
+		matches, _ = sc.namespaceMatches(s)
+	}
+	if !sc.isolating() {
+		return matches
+	}
+	excluded := make([]string, 0, len(matches))
+	for _, m := range matches {
+		excluded = append(excluded, excludeIsolationTags(m))
+	}
+	return excluded
+}
+
+// isolationTagPrefix starts the tags of the records copied for isolated
+// sinks.
+const isolationTagPrefix = "sink."
+
+// isolated reports whether the records of an enabled sink are copied to a
+// tag of its own, see isolationTag, so the filters of the sink do not apply
+// to the records of other sinks. Fluent Bit applies filters before routing
+// records, so filters matching the records of a sink would apply to every
+// output matching them as well.
+func isolated(spec v1alpha1.SinkSpec) bool {
+	return !spec.Disabled && (spec.SampleRate > 1 ||
+		len(severitiesBelow(spec.MinSeverity)) > 0 ||
+		len(spec.Redact) > 0 ||
+		spec.StripKubernetesMetadata ||
+		spec.MaxRecordBytes > 0 ||
+		spec.Parser != "")
+}
+
+// isolating reports whether any sink is isolated.
+func (sc *Config) isolating() bool {
+	for _, sinks := range []map[string]*v1alpha1.LogSink{sc.sinks, sc.templatedSinks} {
+		for _, s := range sinks {
+			if isolated(s.Spec) {
+				return true
+			}
+		}
+	}
+	for _, s := range sc.clusterSinks {
+		if isolated(s.Spec) {
+			return true
+		}
+	}
+	return false
+}
+
+// isolationTag returns the tag the records of an isolated sink are copied
+// to, sink.ns.<namespace>.<name> for sinks and sink.cluster.<name> for
+// cluster sinks. Names cannot contain _, so the tags are not matched by the
+// match of any namespace.
+func isolationTag(namespace, name string) string {
+	if namespace == "" {
+		return isolationTagPrefix + "cluster." + name
+	}
+	return fmt.Sprintf("%sns.%s.%s", isolationTagPrefix, namespace, name)
+}
+
+// excludeIsolationTags returns a pattern matching the tags matched by match
+// but those of records copied for isolated sinks. Globs that could match
+// them are rewritten as regexes.
+func excludeIsolationTags(match string) string {
+	exclude := "^(?!" + regexp.QuoteMeta(isolationTagPrefix) + ")"
+	switch {
+	case strings.HasPrefix(match, "^"):
+		return exclude + match[1:]
+	case matchesOverlap(match, isolationTagPrefix+"*"):
+		parts := strings.Split(match, "*")
+		for i, p := range parts {
+			parts[i] = regexp.QuoteMeta(p)
+		}
+		return exclude + strings.Join(parts, ".*") + "$"
+	}
+	return match
+}
+
+// excludeSystemNamespacesMatch returns a regex matching every tag but those
+// matched by the Match patterns of SystemNamespaces.
+func (sc *Config) excludeSystemNamespacesMatch() string {
+	patterns := make([]string, 0, len(SystemNamespaces))
+	for _, ns := range SystemNamespaces {
+		parts := strings.Split(sc.namespaceMatch(ns), "*")
+		for i, p := range parts {
+			parts[i] = regexp.QuoteMeta(p)
+		}
+		patterns = append(patterns, strings.Join(parts, ".*"))
+	}
+	return fmt.Sprintf("^(?!(?:%s)$).*$", strings.Join(patterns, "|"))
+}
+
+// MatchDirective renders the directive selecting the records matched by a
+// pattern returned by MatchPatterns. Patterns starting with ^ are regexes
+// rendered as Match_Regex.
+func MatchDirective(match string) string {
+	if strings.HasPrefix(match, "^") {
+		return "Match_Regex " + match
+	}
+	return "Match " + match
+}
+
+// namespaceMatch returns the Match pattern selecting records tagged with the
+// given namespace, built from the template set with WithMatchTemplate.
+func (sc *Config) namespaceMatch(ns string) string {
+	var b strings.Builder
+	err := sc.matchTemplate.Execute(&b, struct{ Namespace string }{ns})
+	if err != nil {
+		log.Printf("unable to execute match template: %s", err)
+		return fmt.Sprintf("*_%s_*", ns)
+	}
+	return b.String()
+}
+
+// normalizeHost lowercases the host and strips any trailing dot so that
+// equivalent hosts render the same address.
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func canonicalNamespace(ns string) string {
+	if ns == "" {
+		return "default"
+	}
+	return ns
 }
 
 func key(s *v1alpha1.LogSink) string {
@@ -256,3 +3063,10 @@ func key(s *v1alpha1.LogSink) string {
 func clusterKey(s *v1alpha1.ClusterLogSink) string {
 	return fmt.Sprintf("%s|%s", s.ClusterName, s.Name)
 }
+
+// SecretKey returns the key of the value of the secret ref in namespace in
+// the map given to RenderWithSecrets. Use an empty namespace for secrets
+// referenced by cluster sinks.
+func SecretKey(namespace string, ref v1alpha1.SecretRef) string {
+	return fmt.Sprintf("%s|%s|%s", namespace, ref.Name, ref.Key)
+}
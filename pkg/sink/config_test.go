@@ -18,6 +18,8 @@ package sink_test
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
 	"github.com/knative/observability/pkg/sink"
 	"github.com/knative/observability/pkg/sink/flbconfig"
+	"sigs.k8s.io/yaml"
 )
 
 var emptyConfig = `
@@ -43,6 +46,76 @@ func TestEmptyConfig(t *testing.T) {
 	}
 }
 
+func TestEmptyConfigWithStatsAlias(t *testing.T) {
+	config := sink.NewConfig("127.0.0.1:5000", sink.WithStatsAlias("some-alias")).String()
+	expected := emptyConfig + "    Alias some-alias\n"
+	if config != expected {
+		t.Errorf("Empty Config not equal: Expected: %s Actual: %s", expected, config)
+	}
+}
+
+func TestEmptyConfigWithNullMatch(t *testing.T) {
+	testCases := map[string]struct {
+		match    string
+		expected string
+	}{
+		"default": {
+			expected: emptyConfig,
+		},
+		"tag": {
+			match: "stats.some-tag",
+			expected: `
+[OUTPUT]
+    Name null
+    Match stats.some-tag
+    StatsAddr 127.0.0.1:5000
+`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config := sink.NewConfig("127.0.0.1:5000", sink.WithNullMatch(tc.match)).String()
+			if config != tc.expected {
+				t.Errorf("Empty Config not equal: Expected: %s Actual: %s", tc.expected, config)
+			}
+		})
+	}
+}
+
+func TestEmptyConfigWithSuppressNullConfig(t *testing.T) {
+	config := sink.NewConfig("127.0.0.1:5000", sink.WithSuppressNullConfig(false)).String()
+	if config != emptyConfig {
+		t.Errorf("Empty Config not equal: Expected: %s Actual: %s", emptyConfig, config)
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000", sink.WithSuppressNullConfig(true))
+	if config := sc.String(); config != "" {
+		t.Errorf("expected an empty config, got: %s", config)
+	}
+	if byType := sc.StringByType(); len(byType) != 0 {
+		t.Errorf("expected no output types, got: %v", byType)
+	}
+
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+			Disabled: true,
+		},
+	})
+	if config := sc.String(); config != "" {
+		t.Errorf("expected an empty config with only a disabled sink, got: %s", config)
+	}
+}
+
 func TestSingleSink(t *testing.T) {
 	sc := sink.NewConfig("127.0.0.1:5000")
 	sink := &v1alpha1.LogSink{
@@ -220,12 +293,14 @@ func TestMultipleClusterSinks(t *testing.T) {
 		[]namespaceSink{},
 		[]clusterSink{
 			{
-				Name: "some-name-1",
-				Addr: "example.com:12345",
+				Name:              "some-name-1",
+				Addr:              "example.com:12345",
+				ExcludeNamespaces: sink.SystemNamespaces,
 			},
 			{
-				Name: "some-name-2",
-				Addr: "example.org:45678",
+				Name:              "some-name-2",
+				Addr:              "example.org:45678",
+				ExcludeNamespaces: sink.SystemNamespaces,
 			},
 		},
 	)
@@ -282,8 +357,9 @@ func TestSinksWithClusterSinks(t *testing.T) {
 		},
 		[]clusterSink{
 			{
-				Name: "some-name-2",
-				Addr: "example.org:45678",
+				Name:              "some-name-2",
+				Addr:              "example.org:45678",
+				ExcludeNamespaces: sink.SystemNamespaces,
 			},
 		},
 	)
@@ -377,8 +453,9 @@ func TestRemoveSink(t *testing.T) {
 		[]namespaceSink{},
 		[]clusterSink{
 			{
-				Name: "some-name-2",
-				Addr: "example2.com:12345",
+				Name:              "some-name-2",
+				Addr:              "example2.com:12345",
+				ExcludeNamespaces: sink.SystemNamespaces,
 			},
 		},
 	)
@@ -493,8 +570,70 @@ func TestUpdateSink(t *testing.T) {
 		},
 		[]clusterSink{
 			{
-				Name: "some-name-1",
-				Addr: "cl.sample.org:45678",
+				Name:              "some-name-1",
+				Addr:              "cl.sample.org:45678",
+				ExcludeNamespaces: sink.SystemNamespaces,
+			},
+		},
+	)
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestUpsertCopiesSinks(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	s := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name-1",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "ns.example.com",
+				Port: 12345,
+			},
+		},
+	}
+	cs := &v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-name-1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "cl.example.org",
+				Port: 45678,
+			},
+		},
+	}
+
+	sc.UpsertSink(s)
+	sc.UpsertClusterSink(cs)
+	s.Spec.Host = "ns.sample.com"
+	cs.Spec.Host = "cl.sample.org"
+
+	config := sc.String()
+
+	f, err := flbconfig.Parse("", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Name:      "some-name-1",
+				Addr:      "ns.example.com:12345",
+				Namespace: "ns1",
+			},
+		},
+		[]clusterSink{
+			{
+				Name:              "some-name-1",
+				Addr:              "cl.example.org:45678",
+				ExcludeNamespaces: sink.SystemNamespaces,
 			},
 		},
 	)
@@ -503,6 +642,189 @@ func TestUpdateSink(t *testing.T) {
 	}
 }
 
+func TestReset(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name-1",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-name-2",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "https://example.com/some/path",
+			},
+		},
+	})
+
+	sc.Reset()
+
+	if sc.String() != emptyConfig {
+		t.Errorf("Empty Config not equal: Expected: %s Actual: %s", emptyConfig, sc.String())
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	sinks := []*v1alpha1.LogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-name-1",
+				Namespace: "ns1",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-name-2",
+				Namespace: "ns2",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.org",
+					Port: 45678,
+				},
+			},
+		},
+	}
+	clusterSinks := []*v1alpha1.ClusterLogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "some-name-3",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.net",
+					Port: 514,
+				},
+			},
+		},
+	}
+
+	expected := sink.NewConfig("127.0.0.1:5000")
+	for _, s := range sinks {
+		expected.UpsertSink(s)
+	}
+	for _, cs := range clusterSinks {
+		expected.UpsertClusterSink(cs)
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stale",
+			Namespace: "ns3",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "stale.example.com",
+				Port: 514,
+			},
+		},
+	})
+	sc.ReplaceAll(sinks, clusterSinks)
+
+	if sc.String() != expected.String() {
+		t.Errorf("Config not equal: Expected: %s Actual: %s", expected.String(), sc.String())
+	}
+
+	sinks[0].Spec.Host = "mutated.example.com"
+	if sc.String() != expected.String() {
+		t.Errorf("Config changed after mutating replaced sink: %s", sc.String())
+	}
+}
+
+func TestNewConfigWithSinks(t *testing.T) {
+	sinks := []*v1alpha1.LogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-name-1",
+				Namespace: "ns1",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-name-2",
+				Namespace: "ns2",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.org/some/path",
+				},
+			},
+		},
+	}
+	clusterSinks := []*v1alpha1.ClusterLogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "some-name-3",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.net",
+					Port: 514,
+				},
+			},
+		},
+	}
+
+	expected := sink.NewConfig("127.0.0.1:5000", sink.WithHTTPPluginVersion(sink.HTTPPluginV2))
+	for _, s := range sinks {
+		expected.UpsertSink(s)
+	}
+	for _, cs := range clusterSinks {
+		expected.UpsertClusterSink(cs)
+	}
+
+	sc, err := sink.NewConfigWithSinks(
+		"127.0.0.1:5000",
+		sinks,
+		clusterSinks,
+		sink.WithHTTPPluginVersion(sink.HTTPPluginV2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.String() != expected.String() {
+		t.Errorf("Config not equal: Expected: %s Actual: %s", expected.String(), sc.String())
+	}
+
+	sinks[0].Spec.Host = "mutated.example.com"
+	clusterSinks[0].Spec.Host = "mutated.example.net"
+	if sc.String() != expected.String() {
+		t.Errorf("Config changed after mutating preloaded sinks: %s", sc.String())
+	}
+}
+
 func TestUpdateConcurrency(t *testing.T) {
 	sc := sink.NewConfig("127.0.0.1:5000")
 	s1 := &v1alpha1.LogSink{
@@ -562,8 +884,9 @@ func TestUpdateConcurrency(t *testing.T) {
 		},
 		[]clusterSink{
 			{
-				Name: "some-name-2",
-				Addr: "example.org:45678",
+				Name:              "some-name-2",
+				Addr:              "example.org:45678",
+				ExcludeNamespaces: sink.SystemNamespaces,
 			},
 		},
 	)
@@ -684,7 +1007,91 @@ func TestSinkOrdering(t *testing.T) {
 	}
 }
 
+func TestSortByAddr(t *testing.T) {
+	syslogSink := func(namespace, name, host string, port int) *v1alpha1.LogSink {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: host,
+					Port: port,
+				},
+			},
+		}
+	}
+	syslogClusterSink := func(name, host string, port int) *v1alpha1.ClusterLogSink {
+		return &v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: host,
+					Port: port,
+				},
+				IncludeNamespaces: []string{"a-ns1"},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		opts                 []sink.ConfigOption
+		expectedSinks        []namespaceSink
+		expectedClusterSinks []clusterSink
+	}{
+		"by namespace and name": {
+			expectedSinks: []namespaceSink{
+				{Name: "some-name-3", Addr: "example.org:12345", Namespace: "a-ns1"},
+				{Name: "some-name-1", Addr: "example.com:12345", Namespace: "z-ns2"},
+				{Name: "some-name-2", Addr: "example.org:45678", Namespace: "z-ns2"},
+			},
+			expectedClusterSinks: []clusterSink{
+				{Name: "some-cluster-name-1", Addr: "example.org:514", Namespaces: []string{"a-ns1"}},
+				{Name: "some-cluster-name-2", Addr: "example.com:514", Namespaces: []string{"a-ns1"}},
+			},
+		},
+		"by address and name": {
+			opts: []sink.ConfigOption{sink.WithSortByAddr(true)},
+			expectedSinks: []namespaceSink{
+				{Name: "some-name-1", Addr: "example.com:12345", Namespace: "z-ns2"},
+				{Name: "some-name-3", Addr: "example.org:12345", Namespace: "a-ns1"},
+				{Name: "some-name-2", Addr: "example.org:45678", Namespace: "z-ns2"},
+			},
+			expectedClusterSinks: []clusterSink{
+				{Name: "some-cluster-name-2", Addr: "example.com:514", Namespaces: []string{"a-ns1"}},
+				{Name: "some-cluster-name-1", Addr: "example.org:514", Namespaces: []string{"a-ns1"}},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000", tc.opts...)
+			sc.UpsertSink(syslogSink("z-ns2", "some-name-2", "example.org", 45678))
+			sc.UpsertSink(syslogSink("a-ns1", "some-name-3", "example.org", 12345))
+			sc.UpsertSink(syslogSink("z-ns2", "some-name-1", "example.com", 12345))
+			sc.UpsertClusterSink(syslogClusterSink("some-cluster-name-2", "example.com", 514))
+			sc.UpsertClusterSink(syslogClusterSink("some-cluster-name-1", "example.org", 514))
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(t, tc.expectedSinks, tc.expectedClusterSinks)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
 func TestTlsEncoding(t *testing.T) {
+	insecure := true
 	sc := sink.NewConfig("127.0.0.1:5000")
 	s1 := &v1alpha1.LogSink{
 		ObjectMeta: metav1.ObjectMeta{
@@ -735,9 +1142,10 @@ func TestTlsEncoding(t *testing.T) {
 		},
 		[]clusterSink{
 			{
-				Name: "some-name-2",
-				Addr: "example.com:12345",
-				TLS:  &tlsConfig{},
+				Name:              "some-name-2",
+				Addr:              "example.com:12345",
+				TLS:               &tlsConfig{},
+				ExcludeNamespaces: sink.SystemNamespaces,
 			},
 		},
 	)
@@ -745,8 +1153,8 @@ func TestTlsEncoding(t *testing.T) {
 		t.Fatal(cmp.Diff(f, expectedConfig))
 	}
 
-	s1.Spec.InsecureSkipVerify = true
-	s2.Spec.InsecureSkipVerify = true
+	s1.Spec.InsecureSkipVerify = &insecure
+	s2.Spec.InsecureSkipVerify = &insecure
 
 	sc.UpsertSink(s1)
 	sc.UpsertClusterSink(s2)
@@ -776,6 +1184,7 @@ func TestTlsEncoding(t *testing.T) {
 				TLS: &tlsConfig{
 					InsecureSkipVerify: true,
 				},
+				ExcludeNamespaces: sink.SystemNamespaces,
 			},
 		},
 	)
@@ -1100,8 +1509,8 @@ func TestWebhookSinks(t *testing.T) {
 							Value: "http",
 						},
 						{
-							Key:   "Match",
-							Value: "*",
+							Key:   "Match_Regex",
+							Value: systemNamespacesExcluded,
 						},
 						{
 							Key:   "Format",
@@ -1223,39 +1632,6026 @@ func TestWebhookSinks(t *testing.T) {
 	}
 }
 
-type clusterSink struct {
-	Addr string     `json:"addr,omitempty"`
-	TLS  *tlsConfig `json:"tls,omitempty"`
-	Name string     `json:"name,omitempty"`
-}
-
-type namespaceSink struct {
-	Addr      string     `json:"addr,omitempty"`
-	Namespace string     `json:"namespace,omitempty"`
-	TLS       *tlsConfig `json:"tls,omitempty"`
-	Name      string     `json:"name,omitempty"`
-}
-
-type tlsConfig struct {
-	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
-}
-
-var compareFLBConfig = cmp.Comparer(func(x, y flbconfig.File) bool {
-	if x.Name != y.Name {
-		fmt.Println("file names do not match")
-		return false
+func TestReproConfig(t *testing.T) {
+	reproInput := func(namespace, tag string) flbconfig.Section {
+		return flbconfig.Section{
+			Name: "INPUT",
+			KeyValues: []flbconfig.KeyValue{
+				{Key: "Name", Value: "dummy"},
+				{Key: "Tag", Value: tag},
+				{
+					Key:   "Dummy",
+					Value: `{"kubernetes":{"namespace_name":"` + namespace + `"},"log":"reproduction record"}`,
+				},
+			},
+		}
 	}
-	if len(x.Sections) != len(y.Sections) {
-		fmt.Printf(
-			"section lengths differ: %d != %d\n",
-			len(x.Sections),
-			len(y.Sections),
-		)
-		return false
+	nullOutput := flbconfig.Section{
+		Name: "OUTPUT",
+		KeyValues: []flbconfig.KeyValue{
+			{Key: "Name", Value: "null"},
+			{Key: "Match", Value: "*"},
+			{Key: "StatsAddr", Value: "127.0.0.1:5000"},
+		},
 	}
 
-	ySections := make([]flbconfig.Section, len(y.Sections))
-	copy(ySections, y.Sections)
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "syslog-sink",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "webhook-sink",
+			Namespace: "ns2",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "https://example.com/some/path",
+			},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-sink",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.org",
+				Port: 45678,
+			},
+		},
+	})
+
+	t.Run("syslog", func(t *testing.T) {
+		config, err := sc.ReproConfig("ns1", "syslog-sink")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := flbconfig.Parse("", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedConfig := sinksToConfigAST(
+			t,
+			[]namespaceSink{
+				{
+					Name:      "syslog-sink",
+					Addr:      "example.com:12345",
+					Namespace: "ns1",
+				},
+			},
+			[]clusterSink{},
+			reproInput("ns1", "repro_ns1_repro"),
+			nullOutput,
+		)
+		if !cmp.Equal(f, expectedConfig) {
+			t.Fatal(cmp.Diff(f, expectedConfig))
+		}
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		config, err := sc.ReproConfig("ns2", "webhook-sink")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := flbconfig.Parse("", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedConfig := sinksToConfigAST(
+			t,
+			[]namespaceSink{},
+			[]clusterSink{},
+			reproInput("ns2", "repro_ns2_repro"),
+			nullOutput,
+			flbconfig.Section{
+				Name: "OUTPUT",
+				KeyValues: []flbconfig.KeyValue{
+					{Key: "Name", Value: "http"},
+					{Key: "Match", Value: "*_ns2_*"},
+					{Key: "Format", Value: "json"},
+					{Key: "Host", Value: "example.com"},
+					{Key: "Port", Value: "443"},
+					{Key: "URI", Value: "/some/path"},
+					{Key: "tls", Value: "On"},
+				},
+			},
+		)
+		if !cmp.Equal(f, expectedConfig) {
+			t.Fatal(cmp.Diff(f, expectedConfig))
+		}
+	})
+
+	t.Run("cluster sink", func(t *testing.T) {
+		config, err := sc.ReproConfig("", "cluster-sink")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := flbconfig.Parse("", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedConfig := sinksToConfigAST(
+			t,
+			[]namespaceSink{},
+			[]clusterSink{
+				{
+					Name:              "cluster-sink",
+					Addr:              "example.org:45678",
+					ExcludeNamespaces: sink.SystemNamespaces,
+				},
+			},
+			reproInput("default", "repro_default_repro"),
+			nullOutput,
+		)
+		if !cmp.Equal(f, expectedConfig) {
+			t.Fatal(cmp.Diff(f, expectedConfig))
+		}
+	})
+
+	t.Run("yaml format", func(t *testing.T) {
+		structured := sink.NewConfig("127.0.0.1:5000", sink.WithFormat(sink.YAMLFormat))
+		structured.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "webhook-sink",
+				Namespace: "ns2",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+			},
+		})
+
+		config, err := structured.ReproConfig("ns2", "webhook-sink")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed struct {
+			Pipeline map[string][]map[string]interface{} `json:"pipeline"`
+		}
+		if err := yaml.UnmarshalStrict([]byte(config), &parsed); err != nil {
+			t.Fatalf("unable to parse YAML config: %s\n%s", err, config)
+		}
+		inputs, outputs := parsed.Pipeline["inputs"], parsed.Pipeline["outputs"]
+		if len(inputs) != 1 || inputs[0]["tag"] != "repro_ns2_repro" {
+			t.Errorf("expected the dummy input, got: %v", inputs)
+		}
+		if len(outputs) != 2 || outputs[0]["name"] != "null" || outputs[1]["name"] != "http" {
+			t.Errorf("expected the null and http outputs, got: %v", outputs)
+		}
+	})
+
+	t.Run("health output and sink templates", func(t *testing.T) {
+		webhook := &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "webhook-sink",
+				Namespace: "ns2",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+			},
+		}
+		monitored := sink.NewConfig(
+			"127.0.0.1:5000",
+			sink.WithHealthOutput("heartbeat", "https://monitoring.example.com/heartbeat"),
+			sink.WithSinkTemplates(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "templated-sink",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "https://{{.Namespace}}.example.com/logs",
+					},
+				},
+			}),
+		)
+		monitored.AddNamespace("ns2")
+		monitored.UpsertSink(webhook)
+
+		config, err := monitored.ReproConfig("ns2", "webhook-sink")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := sc.ReproConfig("ns2", "webhook-sink")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config != expected {
+			t.Errorf("expected only the webhook sink: Expected: %s Actual: %s", expected, config)
+		}
+	})
+
+	t.Run("cluster sinks with included namespaces", func(t *testing.T) {
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "included-syslog-sink",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.org",
+					Port: 45678,
+				},
+				IncludeNamespaces: []string{"ns3", "ns4"},
+			},
+		})
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "included-webhook-sink",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.org/some/path",
+				},
+				IncludeNamespaces: []string{"ns3", "ns4"},
+			},
+		})
+
+		for _, name := range []string{"included-syslog-sink", "included-webhook-sink"} {
+			config, err := sc.ReproConfig("", name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := flbconfig.Parse("", config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if input := reproInput("ns3", "repro_ns3_repro"); !cmp.Equal(f.Sections[1], input) {
+				t.Errorf("%s: %s", name, cmp.Diff(f.Sections[1], input))
+			}
+		}
+	})
+
+	t.Run("disabled sink", func(t *testing.T) {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "disabled-sink",
+				Namespace: "ns1",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+				Disabled: true,
+			},
+		})
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "hostless-sink",
+				Namespace: "ns1",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+			},
+		})
+
+		for _, name := range []string{"disabled-sink", "hostless-sink"} {
+			if _, err := sc.ReproConfig("ns1", name); err == nil {
+				t.Errorf("expected an error for %s", name)
+			}
+		}
+	})
+
+	t.Run("unknown sink", func(t *testing.T) {
+		_, err := sc.ReproConfig("ns1", "missing")
+		if err == nil {
+			t.Fatal("expected an error for an unknown sink")
+		}
+	})
+}
+
+func TestHTTPPluginVersion(t *testing.T) {
+	testCases := map[string]struct {
+		opts        []sink.ConfigOption
+		tlsKeyValue flbconfig.KeyValue
+	}{
+		"default": {
+			tlsKeyValue: flbconfig.KeyValue{Key: "tls", Value: "On"},
+		},
+		"v1": {
+			opts:        []sink.ConfigOption{sink.WithHTTPPluginVersion(sink.HTTPPluginV1)},
+			tlsKeyValue: flbconfig.KeyValue{Key: "tls", Value: "On"},
+		},
+		"v2": {
+			opts:        []sink.ConfigOption{sink.WithHTTPPluginVersion(sink.HTTPPluginV2)},
+			tlsKeyValue: flbconfig.KeyValue{Key: "tls.on", Value: "On"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000", tc.opts...)
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "https://example.com/some/path",
+					},
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				httpOutputSection(
+					"*_some-namespace_*",
+					"example.com",
+					"443",
+					"/some/path",
+					tc.tlsKeyValue,
+				),
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestParseHTTPPluginVersion(t *testing.T) {
+	for _, v := range []sink.HTTPPluginVersion{sink.HTTPPluginV1, sink.HTTPPluginV2} {
+		parsed, err := sink.ParseHTTPPluginVersion(string(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != v {
+			t.Fatalf("expected %s, got %s", v, parsed)
+		}
+	}
+
+	for _, s := range []string{"", "v3", "V2"} {
+		if _, err := sink.ParseHTTPPluginVersion(s); err == nil {
+			t.Fatalf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestKeyCase(t *testing.T) {
+	upsertSinks := func(sc *sink.Config) {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-syslog-name",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		})
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-webhook-name",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+			},
+		})
+	}
+	titleConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Addr:      "example.com:12345",
+				Namespace: "some-namespace",
+				Name:      "some-syslog-name",
+			},
+		},
+		[]clusterSink{},
+		httpOutputSection(
+			"*_some-namespace_*",
+			"example.com",
+			"443",
+			"/some/path",
+			flbconfig.KeyValue{Key: "tls", Value: "On"},
+		),
+	)
+	lowerConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Addr:      "example.com:12345",
+				Namespace: "some-namespace",
+				Name:      "some-syslog-name",
+			},
+		},
+		[]clusterSink{},
+		httpOutputSection(
+			"*_some-namespace_*",
+			"example.com",
+			"443",
+			"/some/path",
+			flbconfig.KeyValue{Key: "tls", Value: "On"},
+		),
+	)
+	for _, section := range lowerConfig.Sections {
+		for i, kv := range section.KeyValues {
+			section.KeyValues[i].Key = strings.ToLower(kv.Key)
+		}
+	}
+
+	testCases := map[string]struct {
+		opts               []sink.ConfigOption
+		expectedNullConfig string
+		expectedConfig     flbconfig.File
+	}{
+		"default": {
+			expectedNullConfig: emptyConfig,
+			expectedConfig:     titleConfig,
+		},
+		"title case": {
+			opts:               []sink.ConfigOption{sink.WithKeyCase(sink.TitleCase)},
+			expectedNullConfig: emptyConfig,
+			expectedConfig:     titleConfig,
+		},
+		"lower case": {
+			opts: []sink.ConfigOption{sink.WithKeyCase(sink.LowerCase)},
+			expectedNullConfig: `
+[OUTPUT]
+    name null
+    match *
+    statsaddr 127.0.0.1:5000
+`,
+			expectedConfig: lowerConfig,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000", tc.opts...)
+			if sc.String() != tc.expectedNullConfig {
+				t.Errorf("Empty Config not equal: Expected: %s Actual: %s", tc.expectedNullConfig, sc.String())
+			}
+
+			upsertSinks(sc)
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(f, tc.expectedConfig, compareFLBConfig) {
+				t.Fatal(cmp.Diff(f, tc.expectedConfig))
+			}
+		})
+	}
+}
+
+func TestRenderCheckedMaxBytes(t *testing.T) {
+	s := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	}
+	unlimited := sink.NewConfig("127.0.0.1:5000")
+	unlimited.UpsertSink(s)
+	expected := unlimited.String()
+
+	testCases := map[string]struct {
+		maxBytes    int
+		expectedErr bool
+	}{
+		"unlimited": {
+			maxBytes: 0,
+		},
+		"above the limit": {
+			maxBytes:    len(expected) - 1,
+			expectedErr: true,
+		},
+		"at the limit": {
+			maxBytes: len(expected),
+		},
+		"below the limit": {
+			maxBytes: len(expected) + 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000", sink.WithMaxBytes(tc.maxBytes))
+			sc.UpsertSink(s)
+
+			config, err := sc.RenderChecked()
+			if config != expected {
+				t.Errorf("Config not equal: Expected: %s Actual: %s", expected, config)
+			}
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if !strings.Contains(err.Error(), "with 1 sinks") {
+					t.Errorf("expected error to include the sink count, got %s", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+		})
+	}
+}
+
+func TestLastRenderError(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	if err := sc.LastRenderError(); err != nil {
+		t.Fatalf("expected no render error before rendering, got %s", err)
+	}
+
+	invalid := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: ":@:@:@$",
+			},
+		},
+	}
+	sc.UpsertSink(invalid)
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "valid",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Name:      "valid",
+				Addr:      "example.com:12345",
+				Namespace: "ns1",
+			},
+		},
+		[]clusterSink{},
+	)
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+	if sc.LastRenderError() == nil {
+		t.Fatal("expected a render error for the invalid sink")
+	}
+
+	sc.DeleteSink(invalid)
+	_ = sc.String()
+
+	if err := sc.LastRenderError(); err != nil {
+		t.Fatalf("expected render error to be cleared, got %s", err)
+	}
+}
+
+func TestDynamicRetryLimit(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "syslog-sink",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "webhook-sink",
+			Namespace: "ns2",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+		},
+	})
+
+	sc.SetDynamicRetryLimit("ns1", "syslog-sink", 2)
+	sc.SetDynamicRetryLimit("ns2", "webhook-sink", 3)
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Name:       "syslog-sink",
+				Addr:       "example.com:12345",
+				Namespace:  "ns1",
+				RetryLimit: 2,
+			},
+		},
+		[]clusterSink{},
+		httpOutputSection(
+			"*_ns2_*",
+			"example.com",
+			"80",
+			"/some/path",
+			flbconfig.KeyValue{Key: "Retry_Limit", Value: "3"},
+		),
+	)
+	if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+
+	sc.ClearDynamicRetryLimit("ns1", "syslog-sink")
+	sc.ClearDynamicRetryLimit("ns2", "webhook-sink")
+
+	f, err = flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig = sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Name:      "syslog-sink",
+				Addr:      "example.com:12345",
+				Namespace: "ns1",
+			},
+		},
+		[]clusterSink{},
+		httpOutputSection("*_ns2_*", "example.com", "80", "/some/path"),
+	)
+	if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestWebhookEnableTLS(t *testing.T) {
+	testCases := map[string]struct {
+		url            string
+		enableTLS      bool
+		expectedPort   string
+		expectedExtras []flbconfig.KeyValue
+	}{
+		"http with TLS enabled": {
+			url:          "http://example.com/some/path",
+			enableTLS:    true,
+			expectedPort: "80",
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "tls", Value: "On"},
+			},
+		},
+		"http without TLS enabled": {
+			url:          "http://example.com/some/path",
+			expectedPort: "80",
+		},
+		"https without TLS enabled": {
+			url:          "https://example.com/some/path",
+			expectedPort: "443",
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "tls", Value: "On"},
+			},
+		},
+		"https with TLS enabled": {
+			url:          "https://example.com/some/path",
+			enableTLS:    true,
+			expectedPort: "443",
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "tls", Value: "On"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						EnableTLS: tc.enableTLS,
+					},
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: tc.url,
+					},
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				httpOutputSection(
+					"*_some-namespace_*",
+					"example.com",
+					tc.expectedPort,
+					"/some/path",
+					tc.expectedExtras...,
+				),
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestWebhookTimeKey(t *testing.T) {
+	testCases := map[string]struct {
+		timeKey        string
+		dateFormat     string
+		expectedExtras []flbconfig.KeyValue
+	}{
+		"unset": {},
+		"set": {
+			timeKey: "@timestamp",
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "json_date_key", Value: "@timestamp"},
+			},
+		},
+		"iso8601 date format": {
+			timeKey:    "@timestamp",
+			dateFormat: "iso8601",
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "json_date_key", Value: "@timestamp"},
+				{Key: "json_date_format", Value: "iso8601"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					TimeKey:    tc.timeKey,
+					DateFormat: tc.dateFormat,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				httpOutputSection(
+					"*_some-namespace_*",
+					"example.com",
+					"80",
+					"/some/path",
+					tc.expectedExtras...,
+				),
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestDisabledSinks(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-syslog-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-disabled-syslog-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "disabled.example.com",
+				Port: 12345,
+			},
+			Disabled: true,
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-disabled-webhook-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://disabled.example.com/some/path",
+			},
+			Disabled: true,
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-disabled-cluster-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "disabled.example.com",
+				Port: 12345,
+			},
+			Disabled: true,
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Addr:      "example.com:12345",
+				Namespace: "some-namespace",
+				Name:      "some-syslog-name",
+			},
+		},
+		[]clusterSink{},
+	)
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-syslog-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+			Disabled: true,
+		},
+	})
+	if sc.String() != emptyConfig {
+		t.Errorf("Empty Config not equal: Expected: %s Actual: %s", emptyConfig, sc.String())
+	}
+}
+
+func TestGetSink(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	s := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	}
+	cs := &v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+		},
+	}
+	sc.UpsertSink(s)
+	sc.UpsertClusterSink(cs)
+
+	actual, ok := sc.GetSink("some-namespace", "some-name")
+	if !ok {
+		t.Fatal("expected sink to be found")
+	}
+	if actual.Namespace != s.Namespace || actual.Name != s.Name {
+		t.Errorf("expected sink %s/%s, got %s/%s", s.Namespace, s.Name, actual.Namespace, actual.Name)
+	}
+	if !cmp.Equal(actual.Spec, s.Spec) {
+		t.Error(cmp.Diff(actual.Spec, s.Spec))
+	}
+	actual.Spec.Host = "changed.example.com"
+	if stored, _ := sc.GetSink("some-namespace", "some-name"); stored.Spec.Host != "example.com" {
+		t.Errorf("expected stored sink to be unchanged, got host %s", stored.Spec.Host)
+	}
+
+	actualCluster, ok := sc.GetClusterSink("some-cluster-name")
+	if !ok {
+		t.Fatal("expected cluster sink to be found")
+	}
+	if actualCluster.Name != cs.Name {
+		t.Errorf("expected cluster sink %s, got %s", cs.Name, actualCluster.Name)
+	}
+	if !cmp.Equal(actualCluster.Spec, cs.Spec) {
+		t.Error(cmp.Diff(actualCluster.Spec, cs.Spec))
+	}
+
+	if _, ok := sc.GetSink("other-namespace", "some-name"); ok {
+		t.Error("expected sink in other namespace not to be found")
+	}
+	if _, ok := sc.GetSink("some-namespace", "other-name"); ok {
+		t.Error("expected sink with other name not to be found")
+	}
+	if _, ok := sc.GetClusterSink("other-cluster-name"); ok {
+		t.Error("expected cluster sink with other name not to be found")
+	}
+}
+
+func TestForEachSink(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	for _, k := range [][2]string{
+		{"ns-b", "some-name"},
+		{"ns-a", "other-name"},
+		{"ns-a", "some-name"},
+		{"ns-ab", "some-name"},
+	} {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      k[1],
+				Namespace: k[0],
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://example.com/some/path",
+				},
+			},
+		})
+	}
+	for _, name := range []string{"cluster-c", "cluster-a", "cluster-b"} {
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://example.com/some/path",
+				},
+			},
+		})
+	}
+
+	var visited []string
+	sc.ForEachSink(func(s *v1alpha1.LogSink) bool {
+		visited = append(visited, s.Namespace+"/"+s.Name)
+		s.Spec.URL = "http://changed.example.com"
+		return true
+	})
+	expected := []string{"ns-a/other-name", "ns-a/some-name", "ns-ab/some-name", "ns-b/some-name"}
+	if !cmp.Equal(visited, expected) {
+		t.Error(cmp.Diff(visited, expected))
+	}
+	if s, _ := sc.GetSink("ns-a", "some-name"); s.Spec.URL != "http://example.com/some/path" {
+		t.Errorf("expected stored sink to be unchanged, got url %s", s.Spec.URL)
+	}
+
+	visited = nil
+	sc.ForEachSink(func(s *v1alpha1.LogSink) bool {
+		visited = append(visited, s.Namespace+"/"+s.Name)
+		return len(visited) < 2
+	})
+	expected = []string{"ns-a/other-name", "ns-a/some-name"}
+	if !cmp.Equal(visited, expected) {
+		t.Error(cmp.Diff(visited, expected))
+	}
+
+	visited = nil
+	sc.ForEachClusterSink(func(s *v1alpha1.ClusterLogSink) bool {
+		visited = append(visited, s.Name)
+		return true
+	})
+	expected = []string{"cluster-a", "cluster-b", "cluster-c"}
+	if !cmp.Equal(visited, expected) {
+		t.Error(cmp.Diff(visited, expected))
+	}
+
+	visited = nil
+	sc.ForEachClusterSink(func(s *v1alpha1.ClusterLogSink) bool {
+		visited = append(visited, s.Name)
+		return false
+	})
+	expected = []string{"cluster-a"}
+	if !cmp.Equal(visited, expected) {
+		t.Error(cmp.Diff(visited, expected))
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	testCases := map[string]struct {
+		workers        int
+		expectedExtras []flbconfig.KeyValue
+	}{
+		"default": {},
+		"set": {
+			workers: 4,
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "workers", Value: "4"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-webhook-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					Workers: tc.workers,
+				},
+			})
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-syslog-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "syslog",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						Host: "example.com",
+						Port: 12345,
+					},
+					Workers: tc.workers,
+				},
+			})
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other-syslog-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "syslog",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						Host: "example.com",
+						Port: 12346,
+					},
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{
+					{
+						Addr:      "example.com:12346",
+						Namespace: "some-namespace",
+						Name:      "other-syslog-name",
+					},
+					{
+						Addr:      "example.com:12345",
+						Namespace: "some-namespace",
+						Name:      "some-syslog-name",
+					},
+				},
+				[]clusterSink{},
+				httpOutputSection(
+					"*_some-namespace_*",
+					"example.com",
+					"80",
+					"/some/path",
+					tc.expectedExtras...,
+				),
+			)
+			syslogSection := &expectedConfig.Sections[len(expectedConfig.Sections)-1]
+			syslogSection.KeyValues = append(syslogSection.KeyValues, tc.expectedExtras...)
+			if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestOnlyWebhookSinksOmitSyslogOutput(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-syslog-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-webhook-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-webhook-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://cluster.example.com/some/path",
+			},
+		},
+	})
+	sc.DeleteSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-syslog-name",
+			Namespace: "some-namespace",
+		},
+	})
+
+	config := sc.String()
+	if strings.Contains(config, "syslog") {
+		t.Errorf("expected no syslog output, got: %s", config)
+	}
+
+	f, err := flbconfig.Parse("", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{},
+		[]clusterSink{},
+		httpOutputSection("*_some-namespace_*", "example.com", "80", "/some/path"),
+		httpOutputSection(systemNamespacesExcluded, "cluster.example.com", "80", "/some/path"),
+	)
+	if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestDefaultEnableTLS(t *testing.T) {
+	verify := false
+	testCases := map[string]struct {
+		opts        []sink.ConfigOption
+		spec        v1alpha1.SyslogSpec
+		expectedTLS *tlsConfig
+	}{
+		"no default": {
+			spec: v1alpha1.SyslogSpec{},
+		},
+		"default applied": {
+			opts:        []sink.ConfigOption{sink.WithDefaultEnableTLS(true)},
+			spec:        v1alpha1.SyslogSpec{},
+			expectedTLS: &tlsConfig{},
+		},
+		"default applied with insecure skip verify": {
+			opts: []sink.ConfigOption{
+				sink.WithDefaultEnableTLS(true),
+				sink.WithDefaultInsecureSkipVerify(true),
+			},
+			spec: v1alpha1.SyslogSpec{},
+			expectedTLS: &tlsConfig{
+				InsecureSkipVerify: true,
+			},
+		},
+		"sink requires verification": {
+			opts: []sink.ConfigOption{
+				sink.WithDefaultEnableTLS(true),
+				sink.WithDefaultInsecureSkipVerify(true),
+			},
+			spec: v1alpha1.SyslogSpec{
+				InsecureSkipVerify: &verify,
+			},
+			expectedTLS: &tlsConfig{},
+		},
+		"sink disables tls": {
+			opts: []sink.ConfigOption{sink.WithDefaultEnableTLS(true)},
+			spec: v1alpha1.SyslogSpec{
+				DisableTLS: true,
+			},
+		},
+		"sink enables tls": {
+			opts: []sink.ConfigOption{
+				sink.WithDefaultEnableTLS(true),
+				sink.WithDefaultInsecureSkipVerify(true),
+			},
+			spec: v1alpha1.SyslogSpec{
+				EnableTLS: true,
+			},
+			expectedTLS: &tlsConfig{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			spec := tc.spec
+			spec.Host = "example.com"
+			spec.Port = 12345
+
+			sc := sink.NewConfig("127.0.0.1:5000", tc.opts...)
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type:       "syslog",
+					SyslogSpec: spec,
+				},
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type:       "syslog",
+					SyslogSpec: spec,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{
+					{
+						Addr:      "example.com:12345",
+						Namespace: "some-namespace",
+						TLS:       tc.expectedTLS,
+						Name:      "some-name",
+					},
+				},
+				[]clusterSink{
+					{
+						Addr:              "example.com:12345",
+						TLS:               tc.expectedTLS,
+						Name:              "some-cluster-name",
+						ExcludeNamespaces: sink.SystemNamespaces,
+					},
+				},
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestGELFSinks(t *testing.T) {
+	insecure := true
+	testCases := map[string]struct {
+		spec           v1alpha1.SinkSpec
+		expectedMode   string
+		expectedExtras []flbconfig.KeyValue
+	}{
+		"default mode": {
+			spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "graylog.example.com",
+					Port: 12201,
+				},
+			},
+			expectedMode: "udp",
+		},
+		"udp": {
+			spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "graylog.example.com",
+					Port: 12201,
+				},
+				GELFSpec: v1alpha1.GELFSpec{
+					Mode: "udp",
+				},
+			},
+			expectedMode: "udp",
+		},
+		"tls": {
+			spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "graylog.example.com",
+					Port: 12201,
+				},
+				GELFSpec: v1alpha1.GELFSpec{
+					Mode: "tls",
+				},
+			},
+			expectedMode: "tls",
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "tls", Value: "On"},
+			},
+		},
+		"tls with insecure skip verify": {
+			spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:               "graylog.example.com",
+					Port:               12201,
+					InsecureSkipVerify: &insecure,
+				},
+				GELFSpec: v1alpha1.GELFSpec{
+					Mode: "tls",
+				},
+			},
+			expectedMode: "tls",
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "tls", Value: "On"},
+				{Key: "tls.verify", Value: "Off"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: tc.spec,
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: tc.spec,
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				gelfOutputSection("*_some-namespace_*", tc.expectedMode, tc.expectedExtras...),
+				gelfOutputSection(systemNamespacesExcluded, tc.expectedMode, tc.expectedExtras...),
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestDatadogSinks(t *testing.T) {
+	testCases := map[string]struct {
+		spec            v1alpha1.DatadogSpec
+		expectedSection flbconfig.Section
+	}{
+		"minimal": {
+			spec: v1alpha1.DatadogSpec{
+				APIKey: "some-key",
+			},
+			expectedSection: flbconfig.Section{
+				Name: "OUTPUT",
+				KeyValues: []flbconfig.KeyValue{
+					{Key: "Name", Value: "datadog"},
+					{Key: "Match", Value: "*_some-namespace_*"},
+					{Key: "Host", Value: "http-intake.logs.datadoghq.com"},
+					{Key: "tls", Value: "On"},
+					{Key: "apikey", Value: "some-key"},
+				},
+			},
+		},
+		"service and source": {
+			spec: v1alpha1.DatadogSpec{
+				APIKey:  "some-key",
+				Site:    "datadoghq.eu",
+				Service: "some-service",
+				Source:  "some-source",
+			},
+			expectedSection: flbconfig.Section{
+				Name: "OUTPUT",
+				KeyValues: []flbconfig.KeyValue{
+					{Key: "Name", Value: "datadog"},
+					{Key: "Match", Value: "*_some-namespace_*"},
+					{Key: "Host", Value: "http-intake.logs.datadoghq.eu"},
+					{Key: "tls", Value: "On"},
+					{Key: "apikey", Value: "some-key"},
+					{Key: "dd_service", Value: "some-service"},
+					{Key: "dd_source", Value: "some-source"},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type:        "datadog",
+					DatadogSpec: tc.spec,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				tc.expectedSection,
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestAzureSinks(t *testing.T) {
+	testCases := map[string]struct {
+		spec            v1alpha1.AzureSpec
+		expectedSection flbconfig.Section
+	}{
+		"minimal": {
+			spec: v1alpha1.AzureSpec{
+				CustomerID: "some-workspace",
+				SharedKey:  "some-key",
+			},
+			expectedSection: flbconfig.Section{
+				Name: "OUTPUT",
+				KeyValues: []flbconfig.KeyValue{
+					{Key: "Name", Value: "azure"},
+					{Key: "Match", Value: "*_some-namespace_*"},
+					{Key: "Customer_ID", Value: "some-workspace"},
+					{Key: "Shared_Key", Value: "some-key"},
+				},
+			},
+		},
+		"log type": {
+			spec: v1alpha1.AzureSpec{
+				CustomerID: "some-workspace",
+				SharedKey:  "some-key",
+				LogType:    "some-type",
+			},
+			expectedSection: flbconfig.Section{
+				Name: "OUTPUT",
+				KeyValues: []flbconfig.KeyValue{
+					{Key: "Name", Value: "azure"},
+					{Key: "Match", Value: "*_some-namespace_*"},
+					{Key: "Customer_ID", Value: "some-workspace"},
+					{Key: "Shared_Key", Value: "some-key"},
+					{Key: "Log_Type", Value: "some-type"},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type:      "azure",
+					AzureSpec: tc.spec,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				tc.expectedSection,
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+
+			expected := []sink.Destination{
+				{Host: "some-workspace.ods.opinsights.azure.com", Port: 443, Protocol: "tls"},
+			}
+			if d := sc.Destinations(); !cmp.Equal(d, expected) {
+				t.Error(cmp.Diff(d, expected))
+			}
+		})
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	testCases := map[string]struct {
+		sampleRate       int
+		expectedMatch    string
+		isolateSections  []flbconfig.Section
+		expectedSections []flbconfig.Section
+	}{
+		"unset": {
+			expectedMatch: "*_some-namespace_*",
+		},
+		"one": {
+			sampleRate:    1,
+			expectedMatch: "*_some-namespace_*",
+		},
+		"ten": {
+			sampleRate:    10,
+			expectedMatch: "sink.ns.some-namespace.some-name",
+			isolateSections: []flbconfig.Section{
+				isolateFilterSection("*_some-namespace_*", "sink.ns.some-namespace.some-name", "sink_ns_some-namespace_some-name"),
+			},
+			expectedSections: []flbconfig.Section{
+				{
+					Name: "FILTER",
+					KeyValues: []flbconfig.KeyValue{
+						{Key: "Name", Value: "lua"},
+						{Key: "Match", Value: "sink.ns.some-namespace.some-name"},
+						{Key: "call", Value: "sample"},
+						{
+							Key:   "code",
+							Value: "function sample(tag, timestamp, record) if math.random(10) == 1 then return 0, timestamp, record end return -1, 0, 0 end",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					SampleRate: tc.sampleRate,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				append(append(tc.isolateSections,
+					httpOutputSection(tc.expectedMatch, "example.com", "80", "/some/path"),
+				), tc.expectedSections...)...,
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestIsolatedSinks(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sampled",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+			SampleRate: 10,
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unsampled",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12346,
+			},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "everything",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+			IncludeSystemNamespaces: true,
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "sampled-cluster",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/other/path",
+			},
+			SampleRate:              2,
+			IncludeSystemNamespaces: true,
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := make(map[string][]string)
+	for _, s := range f.Sections {
+		var name, match string
+		for _, kv := range s.KeyValues {
+			switch kv.Key {
+			case "Name":
+				name = kv.Value
+			case "Match", "Match_Regex":
+				match = kv.Key + " " + kv.Value
+			}
+		}
+		if name == "http" {
+			name += " " + s.KeyValues[len(s.KeyValues)-1].Value
+		}
+		matches[name] = append(matches[name], match)
+	}
+
+	expected := map[string][]string{
+		"": {""},
+		"rewrite_tag": {
+			"Match *_ns1_*",
+			`Match_Regex ^(?!sink\.).*$`,
+		},
+		"syslog": {
+			`Match_Regex ^(?!sink\.).*$`,
+			"Match sink.*",
+		},
+		"http /some/path":  {`Match_Regex ^(?!sink\.).*$`},
+		"http /other/path": {"Match sink.cluster.sampled-cluster"},
+		"lua": {
+			"Match sink.ns.ns1.sampled",
+			"Match sink.cluster.sampled-cluster",
+		},
+	}
+	if !cmp.Equal(matches, expected) {
+		t.Fatal(cmp.Diff(matches, expected))
+	}
+
+	for _, s := range f.Sections {
+		if len(s.KeyValues) == 0 || s.KeyValues[0].Value != "syslog" {
+			continue
+		}
+		var sinks []map[string]interface{}
+		if err := json.Unmarshal([]byte(s.KeyValues[3].Value), &sinks); err != nil {
+			t.Fatal(err)
+		}
+		if len(sinks) != 1 {
+			t.Fatalf("expected one sink per syslog output, got %v", sinks)
+		}
+		if sinks[0]["name"] == "sampled" && !cmp.Equal(sinks[0]["matches"], []interface{}{"sink.ns.ns1.sampled"}) {
+			t.Errorf("expected the sampled sink to match its tag, got %v", sinks[0])
+		}
+		if sinks[0]["name"] == "unsampled" && sinks[0]["matches"] != nil {
+			t.Errorf("expected the unsampled sink to be routed by namespace, got %v", sinks[0])
+		}
+	}
+}
+
+func TestMaxRecordBytes(t *testing.T) {
+	testCases := map[string]struct {
+		maxRecordBytes   int
+		expectedMatch    string
+		isolateSections  []flbconfig.Section
+		expectedSections []flbconfig.Section
+	}{
+		"unset": {
+			expectedMatch: "*_some-namespace_*",
+		},
+		"32KB": {
+			maxRecordBytes: 32768,
+			expectedMatch:  "sink.ns.some-namespace.some-name",
+			isolateSections: []flbconfig.Section{
+				isolateFilterSection("*_some-namespace_*", "sink.ns.some-namespace.some-name", "sink_ns_some-namespace_some-name"),
+			},
+			expectedSections: []flbconfig.Section{
+				{
+					Name: "FILTER",
+					KeyValues: []flbconfig.KeyValue{
+						{Key: "Name", Value: "lua"},
+						{Key: "Match", Value: "sink.ns.some-namespace.some-name"},
+						{Key: "call", Value: "truncate"},
+						{
+							Key:   "code",
+							Value: `function truncate(tag, timestamp, record) local log = record["log"] if type(log) == "string" and #log > 32768 then record["log"] = string.sub(log, 1, 32768) return 1, timestamp, record end return 0, timestamp, record end`,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					MaxRecordBytes: tc.maxRecordBytes,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				append(append(tc.isolateSections,
+					httpOutputSection(tc.expectedMatch, "example.com", "80", "/some/path"),
+				), tc.expectedSections...)...,
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestMinSeverity(t *testing.T) {
+	testCases := map[string]struct {
+		minSeverity      string
+		expectedMatch    string
+		isolateSections  []flbconfig.Section
+		expectedSections []flbconfig.Section
+	}{
+		"unset": {
+			expectedMatch: "*_some-namespace_*",
+		},
+		"debug": {
+			minSeverity:   "debug",
+			expectedMatch: "*_some-namespace_*",
+		},
+		"warning": {
+			minSeverity:   "warning",
+			expectedMatch: "sink.ns.some-namespace.some-name",
+			isolateSections: []flbconfig.Section{
+				isolateFilterSection("*_some-namespace_*", "sink.ns.some-namespace.some-name", "sink_ns_some-namespace_some-name"),
+			},
+			expectedSections: []flbconfig.Section{
+				{
+					Name: "FILTER",
+					KeyValues: []flbconfig.KeyValue{
+						{Key: "Name", Value: "grep"},
+						{Key: "Match", Value: "sink.ns.some-namespace.some-name"},
+						{Key: "Exclude", Value: "severity ^(notice|info|debug)$"},
+					},
+				},
+			},
+		},
+		"invalid": {
+			minSeverity:   "warn",
+			expectedMatch: "*_some-namespace_*",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					MinSeverity: tc.minSeverity,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				append(append(tc.isolateSections,
+					httpOutputSection(tc.expectedMatch, "example.com", "80", "/some/path"),
+				), tc.expectedSections...)...,
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	syslogSink := func(name, host string) *v1alpha1.LogSink {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: host,
+					Port: 12345,
+				},
+			},
+		}
+	}
+	webhookClusterSink := func(name, url string) *v1alpha1.ClusterLogSink {
+		return &v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: url,
+				},
+			},
+		}
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000")
+	diff, err := sc.Apply(
+		[]*v1alpha1.LogSink{
+			syslogSink("sink-b", "example.com"),
+			syslogSink("sink-a", "example.com"),
+		},
+		[]*v1alpha1.ClusterLogSink{
+			webhookClusterSink("cluster-a", "http://example.com/a"),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDiff := sink.ConfigDiff{
+		Added:        []string{"some-namespace/sink-a", "some-namespace/sink-b"},
+		ClusterAdded: []string{"cluster-a"},
+	}
+	if !cmp.Equal(diff, expectedDiff) {
+		t.Fatal(cmp.Diff(diff, expectedDiff))
+	}
+
+	diff, err = sc.Apply(
+		[]*v1alpha1.LogSink{
+			syslogSink("sink-a", "example.com"),
+			syslogSink("sink-c", "example.com"),
+		},
+		[]*v1alpha1.ClusterLogSink{
+			webhookClusterSink("cluster-a", "http://example.com/changed"),
+			webhookClusterSink("cluster-b", "http://example.com/b"),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDiff = sink.ConfigDiff{
+		Added:          []string{"some-namespace/sink-c"},
+		Removed:        []string{"some-namespace/sink-b"},
+		ClusterAdded:   []string{"cluster-b"},
+		ClusterUpdated: []string{"cluster-a"},
+	}
+	if !cmp.Equal(diff, expectedDiff) {
+		t.Fatal(cmp.Diff(diff, expectedDiff))
+	}
+	if _, ok := sc.GetSink("some-namespace", "sink-b"); ok {
+		t.Error("expected sink-b to be removed")
+	}
+	if cs, _ := sc.GetClusterSink("cluster-a"); cs.Spec.URL != "http://example.com/changed" {
+		t.Errorf("expected cluster-a to be updated, got url %s", cs.Spec.URL)
+	}
+
+	diff, err = sc.Apply(
+		[]*v1alpha1.LogSink{
+			syslogSink("sink-a", "changed.example.com"),
+			syslogSink("sink-c", "example.com"),
+		},
+		[]*v1alpha1.ClusterLogSink{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDiff = sink.ConfigDiff{
+		Updated:        []string{"some-namespace/sink-a"},
+		ClusterRemoved: []string{"cluster-a", "cluster-b"},
+	}
+	if !cmp.Equal(diff, expectedDiff) {
+		t.Fatal(cmp.Diff(diff, expectedDiff))
+	}
+
+	diff, err = sc.Apply(
+		[]*v1alpha1.LogSink{
+			syslogSink("sink-c", "example.com"),
+			syslogSink("sink-a", "changed.example.com"),
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no changes, got %+v", diff)
+	}
+}
+
+func TestApplyAndRender(t *testing.T) {
+	syslogSink := func(name, host string) *v1alpha1.LogSink {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: host,
+					Port: 12345,
+				},
+			},
+		}
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000")
+	var notified int
+	sc.OnChange(func() { notified++ })
+
+	diff, config, err := sc.ApplyAndRender(
+		[]*v1alpha1.LogSink{
+			syslogSink("sink-a", "example.com"),
+			syslogSink("sink-b", "example.com"),
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDiff := sink.ConfigDiff{
+		Added: []string{"some-namespace/sink-a", "some-namespace/sink-b"},
+	}
+	if !cmp.Equal(diff, expectedDiff) {
+		t.Fatal(cmp.Diff(diff, expectedDiff))
+	}
+	if config != sc.String() {
+		t.Errorf("rendered config not equal: Expected: %s Actual: %s", sc.String(), config)
+	}
+	if !strings.Contains(config, `"name":"sink-b"`) {
+		t.Errorf("expected sink-b to be rendered, got:\n%s", config)
+	}
+	if notified != 1 {
+		t.Errorf("expected 1 change notification, got %d", notified)
+	}
+
+	diff, config, err = sc.ApplyAndRender(
+		[]*v1alpha1.LogSink{
+			syslogSink("sink-a", "changed.example.com"),
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDiff = sink.ConfigDiff{
+		Updated: []string{"some-namespace/sink-a"},
+		Removed: []string{"some-namespace/sink-b"},
+	}
+	if !cmp.Equal(diff, expectedDiff) {
+		t.Fatal(cmp.Diff(diff, expectedDiff))
+	}
+	if config != sc.String() {
+		t.Errorf("rendered config not equal: Expected: %s Actual: %s", sc.String(), config)
+	}
+	if strings.Contains(config, `"name":"sink-b"`) || !strings.Contains(config, "changed.example.com") {
+		t.Errorf("expected only the changed sink-a to be rendered, got:\n%s", config)
+	}
+
+	diff, config, err = sc.ApplyAndRender(
+		[]*v1alpha1.LogSink{
+			syslogSink("sink-a", "changed.example.com"),
+		},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no changes, got %+v", diff)
+	}
+	if config != sc.String() {
+		t.Errorf("rendered config not equal: Expected: %s Actual: %s", sc.String(), config)
+	}
+	if notified != 2 {
+		t.Errorf("expected 2 change notifications, got %d", notified)
+	}
+}
+
+func TestOTLPSinks(t *testing.T) {
+	testCases := map[string]struct {
+		spec            v1alpha1.SinkSpec
+		expectedSection flbconfig.Section
+	}{
+		"basic": {
+			spec: v1alpha1.SinkSpec{
+				Type: "otlp",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "otel.example.com",
+					Port: 4318,
+				},
+			},
+			expectedSection: flbconfig.Section{
+				Name: "OUTPUT",
+				KeyValues: []flbconfig.KeyValue{
+					{Key: "Name", Value: "opentelemetry"},
+					{Key: "Match", Value: "*_some-namespace_*"},
+					{Key: "Host", Value: "otel.example.com"},
+					{Key: "Port", Value: "4318"},
+					{Key: "Logs_uri", Value: "/v1/logs"},
+				},
+			},
+		},
+		"headers and tls": {
+			spec: v1alpha1.SinkSpec{
+				Type: "otlp",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:      "otel.example.com",
+					Port:      4318,
+					EnableTLS: true,
+				},
+				OTLPSpec: v1alpha1.OTLPSpec{
+					URI: "/otlp/v1/logs",
+					Headers: map[string]string{
+						"X-Tenant":      "some-tenant",
+						"Authorization": "Bearer some-token",
+					},
+				},
+			},
+			expectedSection: flbconfig.Section{
+				Name: "OUTPUT",
+				KeyValues: []flbconfig.KeyValue{
+					{Key: "Name", Value: "opentelemetry"},
+					{Key: "Match", Value: "*_some-namespace_*"},
+					{Key: "Host", Value: "otel.example.com"},
+					{Key: "Port", Value: "4318"},
+					{Key: "Logs_uri", Value: "/otlp/v1/logs"},
+					{Key: "tls", Value: "On"},
+					{Key: "Header", Value: "Authorization Bearer some-token"},
+					{Key: "Header", Value: "X-Tenant some-tenant"},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: tc.spec,
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				tc.expectedSection,
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	logSink := func(name, host string) *v1alpha1.LogSink {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: host,
+					Port: 12345,
+				},
+			},
+		}
+	}
+	webhookSink := func(name, url string) *v1alpha1.LogSink {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: url,
+				},
+			},
+		}
+	}
+	clusterSink := func(name, host string, namespaces ...string) *v1alpha1.ClusterLogSink {
+		return &v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: host,
+					Port: 12345,
+				},
+				IncludeNamespaces: namespaces,
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		sinks            []*v1alpha1.LogSink
+		clusterSinks     []*v1alpha1.ClusterLogSink
+		expectedWarnings []string
+	}{
+		"disjoint": {
+			sinks: []*v1alpha1.LogSink{
+				logSink("some-name", "example.com"),
+			},
+			clusterSinks: []*v1alpha1.ClusterLogSink{
+				clusterSink("some-cluster-name", "cluster.example.com"),
+				clusterSink("other-cluster-name", "example.com", "other-namespace"),
+			},
+		},
+		"subsumed": {
+			sinks: []*v1alpha1.LogSink{
+				logSink("some-name", "example.com"),
+			},
+			clusterSinks: []*v1alpha1.ClusterLogSink{
+				clusterSink("some-cluster-name", "example.com"),
+			},
+			expectedWarnings: []string{
+				"sink some-namespace/some-name is subsumed by cluster sink some-cluster-name sending to example.com:12345",
+			},
+		},
+		"subsumed by included namespace": {
+			sinks: []*v1alpha1.LogSink{
+				logSink("some-name", "example.com"),
+			},
+			clusterSinks: []*v1alpha1.ClusterLogSink{
+				clusterSink("some-cluster-name", "example.com", "some-namespace"),
+			},
+			expectedWarnings: []string{
+				"sink some-namespace/some-name is subsumed by cluster sink some-cluster-name sending to example.com:12345",
+			},
+		},
+		"name collision": {
+			sinks: []*v1alpha1.LogSink{
+				logSink("some-name", "example.com"),
+			},
+			clusterSinks: []*v1alpha1.ClusterLogSink{
+				clusterSink("some-name", "cluster.example.com"),
+			},
+			expectedWarnings: []string{
+				"sink some-namespace/some-name has the same name as cluster sink some-name",
+			},
+		},
+		"loopback hosts": {
+			sinks: []*v1alpha1.LogSink{
+				logSink("localhost-name", "LOCALHOST"),
+				logSink("ipv4-name", "127.0.0.1"),
+				logSink("ipv6-name", "::1"),
+				webhookSink("webhook-name", "http://localhost:8080/some/path"),
+				webhookSink("webhook-ipv6-name", "https://[::1]/some/path"),
+			},
+			clusterSinks: []*v1alpha1.ClusterLogSink{
+				clusterSink("some-cluster-name", "127.1.2.3", "other-namespace"),
+			},
+			expectedWarnings: []string{
+				"cluster sink some-cluster-name sends to loopback host 127.1.2.3",
+				"sink some-namespace/ipv4-name sends to loopback host 127.0.0.1",
+				"sink some-namespace/ipv6-name sends to loopback host ::1",
+				"sink some-namespace/localhost-name sends to loopback host LOCALHOST",
+				"sink some-namespace/webhook-ipv6-name sends to loopback host ::1",
+				"sink some-namespace/webhook-name sends to loopback host localhost",
+			},
+		},
+		"external hosts": {
+			sinks: []*v1alpha1.LogSink{
+				logSink("some-name", "10.0.0.1"),
+				logSink("other-name", "localhost.example.com"),
+				webhookSink("webhook-name", "https://example.com/some/path"),
+			},
+			clusterSinks: []*v1alpha1.ClusterLogSink{
+				clusterSink("some-cluster-name", "cluster.example.com", "other-namespace"),
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.ReplaceAll(tc.sinks, tc.clusterSinks)
+
+			warnings := sc.Warnings()
+			if !cmp.Equal(warnings, tc.expectedWarnings) {
+				t.Error(cmp.Diff(warnings, tc.expectedWarnings))
+			}
+		})
+	}
+}
+
+func TestMatchTemplate(t *testing.T) {
+	testCases := map[string]struct {
+		opts          []sink.ConfigOption
+		expectedMatch string
+		expectedTag   string
+	}{
+		"default": {
+			expectedMatch: "*_some-namespace_*",
+			expectedTag:   "repro_some-namespace_repro",
+		},
+		"dot delimited": {
+			opts:          []sink.ConfigOption{sink.WithMatchTemplate("kube.{{.Namespace}}.*")},
+			expectedMatch: "kube.some-namespace.*",
+			expectedTag:   "kube.some-namespace.repro",
+		},
+		"invalid": {
+			opts:          []sink.ConfigOption{sink.WithMatchTemplate("kube.{{.Namespace")},
+			expectedMatch: "*_some-namespace_*",
+			expectedTag:   "repro_some-namespace_repro",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000", tc.opts...)
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+				},
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://cluster.example.com/some/path",
+					},
+					IncludeNamespaces: []string{"some-namespace"},
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				httpOutputSection(tc.expectedMatch, "example.com", "80", "/some/path"),
+				httpOutputSection(tc.expectedMatch, "cluster.example.com", "80", "/some/path"),
+			)
+			if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+
+			repro, err := sc.ReproConfig("some-namespace", "some-name")
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err = flbconfig.Parse("", repro)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tag := f.Sections[1].KeyValues[1]; tag.Value != tc.expectedTag {
+				t.Errorf("repro tag not equal: Expected: %s Actual: %s", tc.expectedTag, tag.Value)
+			}
+		})
+	}
+}
+
+func TestStringByType(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000", sink.WithKeyCase(sink.LowerCase))
+
+	byType := sc.StringByType()
+	if !cmp.Equal(byType, map[string]string{"null": sc.String()}) {
+		t.Errorf("expected only the null config, got %v", byType)
+	}
+
+	for _, ns := range []string{"ns-c", "ns-a", "ns-b"} {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-webhook-name",
+				Namespace: ns,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://example.com/" + ns,
+				},
+				SampleRate: 10,
+			},
+		})
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-syslog-name",
+				Namespace: ns,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		})
+	}
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "gelf",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "graylog.example.com",
+				Port: 12201,
+			},
+		},
+	})
+
+	byType = sc.StringByType()
+	var keys []string
+	for k := range byType {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	expectedKeys := []string{"gelf", "isolate", "sample", "syslog", "webhook"}
+	if !cmp.Equal(keys, expectedKeys) {
+		t.Error(cmp.Diff(keys, expectedKeys))
+	}
+
+	var combined string
+	for _, t := range sink.OutputTypes {
+		combined += byType[t]
+	}
+	if combined != sc.String() {
+		t.Errorf("Combined config not equal: Expected: %s Actual: %s", sc.String(), combined)
+	}
+}
+
+func TestOnBackpressure(t *testing.T) {
+	testCases := map[string]struct {
+		onBackpressure string
+		expectedExtras []flbconfig.KeyValue
+	}{
+		"default": {},
+		"drop": {
+			onBackpressure: "drop",
+		},
+		"block": {
+			onBackpressure: "block",
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "storage.pause_on_chunks_overlimit", Value: "On"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-webhook-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					OnBackpressure: tc.onBackpressure,
+					TailSource: &v1alpha1.SourceSpec{
+						Path: "/var/log/audit.log",
+						Tag:  "audit",
+					},
+				},
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-syslog-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "syslog",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						Host: "example.com",
+						Port: 12345,
+					},
+					OnBackpressure: tc.onBackpressure,
+					TailSource: &v1alpha1.SourceSpec{
+						Path: "/var/log/app/*.log",
+						Tag:  "app.logs",
+					},
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{
+					{
+						Addr:           "example.com:12345",
+						Name:           "some-syslog-name",
+						Matches:        []string{"app.logs"},
+						OnBackpressure: tc.onBackpressure,
+					},
+				},
+				flbconfig.Section{
+					Name: "INPUT",
+					KeyValues: append([]flbconfig.KeyValue{
+						{Key: "Name", Value: "tail"},
+						{Key: "Path", Value: "/var/log/app/*.log"},
+						{Key: "Tag", Value: "app.logs"},
+					}, tc.expectedExtras...),
+				},
+				flbconfig.Section{
+					Name: "INPUT",
+					KeyValues: append([]flbconfig.KeyValue{
+						{Key: "Name", Value: "tail"},
+						{Key: "Path", Value: "/var/log/audit.log"},
+						{Key: "Tag", Value: "audit"},
+					}, tc.expectedExtras...),
+				},
+			)
+			expectedConfig.Sections = append(
+				expectedConfig.Sections,
+				httpOutputSection(
+					"audit",
+					"example.com",
+					"80",
+					"/some/path",
+				),
+			)
+			if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestWebhookTLSFiles(t *testing.T) {
+	verify := false
+	testCases := map[string]struct {
+		spec           v1alpha1.WebhookSpec
+		expectedExtras []flbconfig.KeyValue
+	}{
+		"ca only": {
+			spec: v1alpha1.WebhookSpec{
+				URL: "https://example.com/some/path",
+				CA:  "/etc/ssl/ca.pem",
+			},
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "tls", Value: "On"},
+				{Key: "tls.ca_file", Value: "/etc/ssl/ca.pem"},
+			},
+		},
+		"mutual tls": {
+			spec: v1alpha1.WebhookSpec{
+				URL:        "https://example.com/some/path",
+				CA:         "/etc/ssl/ca.pem",
+				ClientCert: "/etc/ssl/client.pem",
+				ClientKey:  "/etc/ssl/client-key.pem",
+				TLSVerify:  &verify,
+			},
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "tls", Value: "On"},
+				{Key: "tls.verify", Value: "Off"},
+				{Key: "tls.ca_file", Value: "/etc/ssl/ca.pem"},
+				{Key: "tls.crt_file", Value: "/etc/ssl/client.pem"},
+				{Key: "tls.key_file", Value: "/etc/ssl/client-key.pem"},
+			},
+		},
+		"vhost": {
+			spec: v1alpha1.WebhookSpec{
+				URL:      "https://example.com/some/path",
+				TLSVHost: "logs.example.com",
+			},
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "tls", Value: "On"},
+				{Key: "tls.vhost", Value: "logs.example.com"},
+			},
+		},
+		"without tls": {
+			spec: v1alpha1.WebhookSpec{
+				URL:      "http://example.com/some/path",
+				CA:       "/etc/ssl/ca.pem",
+				TLSVHost: "logs.example.com",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type:        "webhook",
+					WebhookSpec: tc.spec,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			port := "443"
+			if tc.expectedExtras == nil {
+				port = "80"
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				httpOutputSection(
+					"*_some-namespace_*",
+					"example.com",
+					port,
+					"/some/path",
+					tc.expectedExtras...,
+				),
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestWebhookConnectionDirectives(t *testing.T) {
+	keepAlive, noKeepAlive := true, false
+	testCases := map[string]struct {
+		spec           v1alpha1.WebhookSpec
+		expectedExtras []flbconfig.KeyValue
+	}{
+		"not set": {
+			spec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+		},
+		"keepalive on": {
+			spec: v1alpha1.WebhookSpec{
+				URL:       "http://example.com/some/path",
+				KeepAlive: &keepAlive,
+			},
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "net.keepalive", Value: "On"},
+			},
+		},
+		"keepalive off": {
+			spec: v1alpha1.WebhookSpec{
+				URL:       "http://example.com/some/path",
+				KeepAlive: &noKeepAlive,
+			},
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "net.keepalive", Value: "Off"},
+			},
+		},
+		"connect timeout": {
+			spec: v1alpha1.WebhookSpec{
+				URL:                   "http://example.com/some/path",
+				ConnectTimeoutSeconds: 5,
+			},
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "net.connect_timeout", Value: "5"},
+			},
+		},
+		"both": {
+			spec: v1alpha1.WebhookSpec{
+				URL:                   "http://example.com/some/path",
+				KeepAlive:             &noKeepAlive,
+				ConnectTimeoutSeconds: 30,
+			},
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "net.keepalive", Value: "Off"},
+				{Key: "net.connect_timeout", Value: "30"},
+			},
+		},
+		"http2": {
+			spec: v1alpha1.WebhookSpec{
+				URL:   "http://example.com/some/path",
+				HTTP2: true,
+			},
+			expectedExtras: []flbconfig.KeyValue{
+				{Key: "http2", Value: "On"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type:        "webhook",
+					WebhookSpec: tc.spec,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				httpOutputSection(
+					"*_some-namespace_*",
+					"example.com",
+					"80",
+					"/some/path",
+					tc.expectedExtras...,
+				),
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	testCases := map[string]struct {
+		spec          *v1alpha1.SinkSpec
+		expectedEmpty bool
+	}{
+		"empty": {
+			expectedEmpty: true,
+		},
+		"syslog only": {
+			spec: &v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		},
+		"webhook only": {
+			spec: &v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+			},
+		},
+		"disabled only": {
+			spec: &v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+				Disabled: true,
+			},
+			expectedEmpty: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			if tc.spec != nil {
+				sc.UpsertSink(&v1alpha1.LogSink{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "some-name",
+						Namespace: "some-namespace",
+					},
+					Spec: *tc.spec,
+				})
+			}
+
+			if sc.IsEmpty() != tc.expectedEmpty {
+				t.Fatalf("expected IsEmpty to be %t", tc.expectedEmpty)
+			}
+			isNull := strings.Contains(sc.String(), "Name null")
+			if isNull != tc.expectedEmpty {
+				t.Fatalf("expected IsEmpty to match rendering the null config")
+			}
+		})
+	}
+}
+
+func TestSecretRef(t *testing.T) {
+	ref := v1alpha1.SecretRef{
+		Name: "some-secret",
+		Key:  "ca.pem",
+	}
+	s := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host:        "example.com",
+				Port:        12345,
+				EnableTLS:   true,
+				CASecretRef: &ref,
+			},
+		},
+	}
+
+	t.Run("unresolved", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertSink(s)
+
+		_, err := sc.RenderChecked()
+		unresolved, ok := err.(*sink.ErrUnresolvedSecret)
+		if !ok {
+			t.Fatalf("expected ErrUnresolvedSecret, got: %v", err)
+		}
+		if unresolved.Namespace != "some-namespace" || unresolved.Name != "some-name" {
+			t.Fatalf("expected error to name the sink, got: %s", err)
+		}
+		if unresolved.Ref != ref {
+			t.Fatalf("expected error to name the secret, got: %s", err)
+		}
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertSink(s)
+		sc.SetSecret("some-namespace", ref, "some-ca")
+
+		config, err := sc.RenderChecked()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := flbconfig.Parse("", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedConfig := sinksToConfigAST(
+			t,
+			[]namespaceSink{
+				{
+					Addr:      "example.com:12345",
+					Namespace: "some-namespace",
+					TLS: &tlsConfig{
+						CA: "some-ca",
+					},
+					Name: "some-name",
+				},
+			},
+			[]clusterSink{},
+		)
+		if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+			t.Fatal(cmp.Diff(f, expectedConfig))
+		}
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertSink(s)
+		sc.SetSecret("some-namespace", ref, "some-ca")
+		sc.ClearSecret("some-namespace", ref)
+
+		if _, err := sc.RenderChecked(); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("render with secrets", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertSink(s)
+		sc.SetSecret("some-namespace", ref, "stored-ca")
+		key := sink.SecretKey("some-namespace", ref)
+
+		config, err := sc.RenderWithSecrets(map[string]string{key: "some-ca"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rotated, err := sc.RenderWithSecrets(map[string]string{key: "rotated-ca"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(config, `"tls":{"ca":"some-ca"}`) {
+			t.Errorf("expected the TLS JSON to contain the given CA, got:\n%s", config)
+		}
+		if !strings.Contains(rotated, `"tls":{"ca":"rotated-ca"}`) {
+			t.Errorf("expected the TLS JSON to contain the rotated CA, got:\n%s", rotated)
+		}
+		if strings.Contains(sc.String(), "some-ca") || strings.Contains(sc.String(), "rotated-ca") {
+			t.Errorf("expected the given secrets not to be kept, got:\n%s", sc.String())
+		}
+
+		_, err = sc.RenderWithSecrets(nil)
+		if _, ok := err.(*sink.ErrUnresolvedSecret); !ok {
+			t.Errorf("expected ErrUnresolvedSecret without the secret, got: %v", err)
+		}
+	})
+}
+
+func TestInfiniteRetries(t *testing.T) {
+	testCases := map[string]struct {
+		retryLimit    int
+		expectedExtra flbconfig.KeyValue
+	}{
+		"infinite": {
+			expectedExtra: flbconfig.KeyValue{Key: "Retry_Limit", Value: "no_limits"},
+		},
+		"takes precedence over a retry limit": {
+			retryLimit:    3,
+			expectedExtra: flbconfig.KeyValue{Key: "Retry_Limit", Value: "no_limits"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL:             "http://example.com/some/path",
+						InfiniteRetries: true,
+					},
+				},
+			})
+			if tc.retryLimit > 0 {
+				sc.SetDynamicRetryLimit("some-namespace", "some-name", tc.retryLimit)
+			}
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				httpOutputSection(
+					"*_some-namespace_*",
+					"example.com",
+					"80",
+					"/some/path",
+					tc.expectedExtra,
+				),
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestBufferSizes(t *testing.T) {
+	testCases := map[string]struct {
+		chunkSize            string
+		bufferSize           string
+		expectedInputExtras  []flbconfig.KeyValue
+		expectedOutputExtras []flbconfig.KeyValue
+	}{
+		"defaults": {},
+		"chunk size": {
+			chunkSize: "512K",
+			expectedInputExtras: []flbconfig.KeyValue{
+				{Key: "Buffer_Chunk_Size", Value: "512K"},
+			},
+		},
+		"chunk and buffer size": {
+			chunkSize:  "1M",
+			bufferSize: "64MB",
+			expectedInputExtras: []flbconfig.KeyValue{
+				{Key: "Buffer_Chunk_Size", Value: "1M"},
+			},
+			expectedOutputExtras: []flbconfig.KeyValue{
+				{Key: "storage.total_limit_size", Value: "64MB"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL:        "http://example.com/some/path",
+						ChunkSize:  tc.chunkSize,
+						BufferSize: tc.bufferSize,
+					},
+					TailSource: &v1alpha1.SourceSpec{
+						Path: "/var/log/app/*.log",
+						Tag:  "app.logs",
+					},
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				flbconfig.Section{
+					Name: "INPUT",
+					KeyValues: append([]flbconfig.KeyValue{
+						{Key: "Name", Value: "tail"},
+						{Key: "Path", Value: "/var/log/app/*.log"},
+						{Key: "Tag", Value: "app.logs"},
+					}, tc.expectedInputExtras...),
+				},
+				httpOutputSection(
+					"app.logs",
+					"example.com",
+					"80",
+					"/some/path",
+					tc.expectedOutputExtras...,
+				),
+			)
+			if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	for _, host := range []string{"Collector.", "collector"} {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "syslog-" + host,
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: host,
+					Port: 514,
+				},
+			},
+		})
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "webhook-" + host,
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://" + host + "/some/path",
+				},
+			},
+		})
+	}
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Addr:      "collector:514",
+				Namespace: "some-namespace",
+				Name:      "syslog-Collector.",
+			},
+			{
+				Addr:      "collector:514",
+				Namespace: "some-namespace",
+				Name:      "syslog-collector",
+			},
+		},
+		[]clusterSink{},
+		httpOutputSection("*_some-namespace_*", "collector", "80", "/some/path"),
+		httpOutputSection("*_some-namespace_*", "collector", "80", "/some/path"),
+	)
+	if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestDeleteSinkWithDrain(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	s := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+		},
+	}
+	sc.UpsertSink(s)
+	rendered := sc.String()
+
+	sc.DeleteSinkWithDrain(s, time.Minute)
+	if sc.PurgeExpired(time.Now()) {
+		t.Fatal("expected no sinks to be purged before the grace period")
+	}
+	if sc.String() != rendered {
+		t.Fatalf("expected draining sink to be rendered, got:\n%s", sc.String())
+	}
+
+	if !sc.PurgeExpired(time.Now().Add(2 * time.Minute)) {
+		t.Fatal("expected the sink to be purged after the grace period")
+	}
+	if !sc.IsEmpty() {
+		t.Fatalf("expected draining sink to be removed, got:\n%s", sc.String())
+	}
+}
+
+func TestDeleteSinkWithDrainCanceledByUpsert(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	s := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+		},
+	}
+	sc.UpsertSink(s)
+	sc.DeleteSinkWithDrain(s, time.Minute)
+	sc.UpsertSink(s)
+
+	if sc.PurgeExpired(time.Now().Add(2 * time.Minute)) {
+		t.Fatal("expected upserted sink not to be purged")
+	}
+	if sc.IsEmpty() {
+		t.Fatal("expected upserted sink to be kept")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	insecure := true
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "valid",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "too-many-workers",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+			Workers: v1alpha1.MaxWorkers + 1,
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "insecure",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host:               "example.com",
+				Port:               12345,
+				InsecureSkipVerify: &insecure,
+			},
+		},
+	})
+	rendered := sc.String()
+
+	errs := sc.Validate()
+	var actual []string
+	for _, err := range errs {
+		actual = append(actual, err.Error())
+	}
+	expected := []string{
+		"cluster sink insecure: " + v1alpha1.ErrInsecureSkipVerifyWithoutTLS.Error(),
+		"sink some-namespace/too-many-workers: " + v1alpha1.ErrInvalidWorkers.Error(),
+	}
+	if !cmp.Equal(actual, expected) {
+		t.Fatal(cmp.Diff(actual, expected))
+	}
+	if sc.String() != rendered {
+		t.Fatal("expected Validate not to modify the config")
+	}
+}
+
+func TestDestinations(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "syslog",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "syslog.example.com",
+				Port: 514,
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "syslog",
+			Namespace: "ns2",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "Syslog.example.com.",
+				Port: 514,
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "webhook",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://webhook.example.com/some/path",
+			},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "tls",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host:      "syslog.example.com",
+				Port:      6514,
+				EnableTLS: true,
+			},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "disabled",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "https://disabled.example.com",
+			},
+			Disabled: true,
+		},
+	})
+
+	expected := []sink.Destination{
+		{Host: "syslog.example.com", Port: 514, Protocol: "tcp"},
+		{Host: "syslog.example.com", Port: 6514, Protocol: "tls"},
+		{Host: "webhook.example.com", Port: 80, Protocol: "tcp"},
+	}
+	if !cmp.Equal(sc.Destinations(), expected) {
+		t.Fatal(cmp.Diff(sc.Destinations(), expected))
+	}
+}
+
+func TestCatchAll(t *testing.T) {
+	catchAll := func(name string) *v1alpha1.ClusterLogSink {
+		return &v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://" + name + ".example.com/some/path",
+				},
+				IncludeNamespaces: []string{"ignored"},
+				CatchAll:          true,
+			},
+		}
+	}
+
+	t.Run("rendered last", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertClusterSink(catchAll("default"))
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "otlp",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "otlp",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "otel.example.com",
+					Port: 4318,
+				},
+			},
+		})
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "webhook",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://webhook.example.com/some/path",
+				},
+				IncludeNamespaces: []string{"some-namespace"},
+			},
+		})
+
+		f, err := flbconfig.Parse("", sc.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := f.Sections[len(f.Sections)-1]
+		expected := httpOutputSection(systemNamespacesExcluded, "default.example.com", "80", "/some/path")
+		if !cmp.Equal(last, expected) {
+			t.Fatal(cmp.Diff(last, expected))
+		}
+		if len(sc.Validate()) != 0 {
+			t.Fatalf("expected a single catch-all to be valid, got: %v", sc.Validate())
+		}
+	})
+
+	t.Run("rendered last when named before other sinks", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertClusterSink(catchAll("aaa-fallback"))
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "zzz-specific",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://zzz-specific.example.com/some/path",
+				},
+				IncludeNamespaces: []string{"some-namespace"},
+				Priority:          -10,
+			},
+		})
+
+		f, err := flbconfig.Parse("", sc.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := f.Sections[len(f.Sections)-1]
+		expected := httpOutputSection(systemNamespacesExcluded, "aaa-fallback.example.com", "80", "/some/path")
+		if !cmp.Equal(last, expected) {
+			t.Fatal(cmp.Diff(last, expected))
+		}
+	})
+
+	t.Run("syslog rendered last when named before other sinks", func(t *testing.T) {
+		syslogSink := func(name string, catchAll bool) *v1alpha1.ClusterLogSink {
+			return &v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "syslog",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						Host: name + ".example.com",
+						Port: 514,
+					},
+					IncludeNamespaces: []string{"some-namespace"},
+					CatchAll:          catchAll,
+				},
+			}
+		}
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertClusterSink(syslogSink("aaa-fallback", true))
+		sc.UpsertClusterSink(syslogSink("zzz-specific", false))
+
+		f, err := flbconfig.Parse("", sc.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, s := range f.Sections {
+			for _, kv := range s.KeyValues {
+				if kv.Key != "ClusterSinks" {
+					continue
+				}
+				var sinks []clusterSink
+				if err := json.Unmarshal([]byte(kv.Value), &sinks); err != nil {
+					t.Fatal(err)
+				}
+				for _, s := range sinks {
+					names = append(names, s.Name)
+				}
+			}
+		}
+		expected := []string{"zzz-specific", "aaa-fallback"}
+		if !cmp.Equal(names, expected) {
+			t.Fatal(cmp.Diff(names, expected))
+		}
+	})
+
+	t.Run("multiple", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertClusterSink(catchAll("first"))
+		sc.UpsertClusterSink(catchAll("second"))
+
+		errs := sc.Validate()
+		if len(errs) != 1 {
+			t.Fatalf("expected one error, got: %v", errs)
+		}
+		expected := "cluster sinks first, second are catch-all sinks, only one is allowed"
+		if errs[0].Error() != expected {
+			t.Fatalf("expected %q, got %q", expected, errs[0])
+		}
+	})
+}
+
+func TestClusterSinkPriority(t *testing.T) {
+	webhookSink := func(name string, priority int) *v1alpha1.ClusterLogSink {
+		return &v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://" + name + ".example.com/some/path",
+				},
+				IncludeNamespaces: []string{"some-namespace"},
+				Priority:          priority,
+			},
+		}
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertClusterSink(webhookSink("a", 0))
+	sc.UpsertClusterSink(webhookSink("b", 10))
+	sc.UpsertClusterSink(webhookSink("c", 0))
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{},
+		[]clusterSink{},
+		httpOutputSection("*_some-namespace_*", "b.example.com", "80", "/some/path"),
+		httpOutputSection("*_some-namespace_*", "a.example.com", "80", "/some/path"),
+		httpOutputSection("*_some-namespace_*", "c.example.com", "80", "/some/path"),
+	)
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestWebhookWithoutPort(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "unknown://example.com/some/path",
+			},
+		},
+	})
+
+	config := sc.String()
+	if strings.Contains(config, "Port") {
+		t.Errorf("expected no Port line, got:\n%s", config)
+	}
+	f, err := flbconfig.Parse("", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{},
+		[]clusterSink{},
+		flbconfig.Section{
+			Name: "OUTPUT",
+			KeyValues: []flbconfig.KeyValue{
+				{Key: "Name", Value: "http"},
+				{Key: "Match", Value: "*_some-namespace_*"},
+				{Key: "Format", Value: "json"},
+				{Key: "Host", Value: "example.com"},
+				{Key: "URI", Value: "/some/path"},
+			},
+		},
+	)
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestRouteByField(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+			RouteByField: "kubernetes.namespace_name",
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{},
+		[]clusterSink{},
+		flbconfig.Section{
+			Name: "FILTER",
+			KeyValues: []flbconfig.KeyValue{
+				{Key: "Name", Value: "rewrite_tag"},
+				{Key: "Match_Regex", Value: `^(?!route\.)` + systemNamespacesExcluded[1:]},
+				{Key: "Rule", Value: "$kubernetes['namespace_name'] ^(.+)$ route.some-name.$1 true"},
+				{Key: "Emitter_Name", Value: "route_some-name"},
+			},
+		},
+		httpOutputSection(
+			"route.some-name.*",
+			"example.com",
+			"80",
+			"/some/path",
+			flbconfig.KeyValue{Key: "header_tag", Value: "X-Route-Tag"},
+		),
+	)
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+			Routes: []v1alpha1.RouteSpec{
+				{
+					Field:   "level",
+					Pattern: "^(error|fatal)$",
+					URL:     "https://errors.example.com/error/path",
+				},
+				{
+					Field:   "kubernetes.labels.team",
+					Pattern: "^payments$",
+					URL:     "http://payments.example.com:8080/payments/path",
+				},
+			},
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{},
+		[]clusterSink{},
+		flbconfig.Section{
+			Name: "FILTER",
+			KeyValues: []flbconfig.KeyValue{
+				{Key: "Name", Value: "rewrite_tag"},
+				{Key: "Match_Regex", Value: `^(?!route\.)` + systemNamespacesExcluded[1:]},
+				{Key: "Rule", Value: "$level ^(error|fatal)$ route.some-name.0 false"},
+				{Key: "Rule", Value: "$kubernetes['labels']['team'] ^payments$ route.some-name.1 false"},
+				{Key: "Emitter_Name", Value: "route_some-name"},
+			},
+		},
+		httpOutputSection(
+			"route.some-name.0",
+			"errors.example.com",
+			"443",
+			"/error/path",
+			flbconfig.KeyValue{Key: "tls", Value: "On"},
+		),
+		httpOutputSection(
+			"route.some-name.1",
+			"payments.example.com",
+			"8080",
+			"/payments/path",
+		),
+		httpOutputSection(
+			`^(?!route\.)`+systemNamespacesExcluded[1:],
+			"example.com",
+			"80",
+			"/some/path",
+		),
+	)
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+
+	expectedDestinations := []sink.Destination{
+		{Host: "errors.example.com", Port: 443, Protocol: "tls"},
+		{Host: "example.com", Port: 80, Protocol: "tcp"},
+		{Host: "payments.example.com", Port: 8080, Protocol: "tcp"},
+	}
+	if d := sc.Destinations(); !cmp.Equal(d, expectedDestinations) {
+		t.Error(cmp.Diff(d, expectedDestinations))
+	}
+}
+
+func TestClusterWebhookSinksRenderInStableOrder(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	for _, name := range []string{"charlie", "alpha", "bravo"} {
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://" + name + ".example.com/some/path",
+				},
+			},
+		})
+	}
+
+	expected := sc.String()
+	alpha := strings.Index(expected, "alpha.example.com")
+	bravo := strings.Index(expected, "bravo.example.com")
+	charlie := strings.Index(expected, "charlie.example.com")
+	if !(alpha < bravo && bravo < charlie) {
+		t.Fatalf("expected cluster webhook sinks ordered by name, got:\n%s", expected)
+	}
+	for i := 0; i < 100; i++ {
+		if actual := sc.String(); actual != expected {
+			t.Fatalf("expected identical renders, got:\n%s\nand:\n%s", expected, actual)
+		}
+	}
+}
+
+func TestNaturalSort(t *testing.T) {
+	names := []string{"sink10", "sink2", "sink1", "sink02"}
+	upsertSinks := func(sc *sink.Config) {
+		for _, name := range names {
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/" + name,
+					},
+				},
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "syslog",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						Host: name + ".example.com",
+						Port: 12345,
+					},
+				},
+			})
+		}
+	}
+	order := func(config string, format string) []string {
+		ordered := append([]string{}, names...)
+		sort.Slice(ordered, func(i, j int) bool {
+			return strings.Index(config, fmt.Sprintf(format, ordered[i])) <
+				strings.Index(config, fmt.Sprintf(format, ordered[j]))
+		})
+		return ordered
+	}
+
+	lexical := sink.NewConfig("127.0.0.1:5000")
+	upsertSinks(lexical)
+	natural := sink.NewConfig("127.0.0.1:5000", sink.WithNaturalSort(true))
+	upsertSinks(natural)
+
+	for _, tc := range []struct {
+		config   string
+		expected []string
+	}{
+		{lexical.String(), []string{"sink02", "sink1", "sink10", "sink2"}},
+		{natural.String(), []string{"sink1", "sink02", "sink2", "sink10"}},
+	} {
+		for _, format := range []string{"URI /%s\n", `"name":"%s"`} {
+			if actual := order(tc.config, format); !cmp.Equal(actual, tc.expected) {
+				t.Errorf("expected %v ordered %v, got %v in:\n%s", format, tc.expected, actual, tc.config)
+			}
+		}
+	}
+}
+
+func TestSinkAliases(t *testing.T) {
+	upsertSinks := func(sc *sink.Config) {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "syslog-sink",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		})
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "webhook-sink",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://example.com/some/path",
+				},
+			},
+		})
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "cluster-webhook-sink",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://example.com/other/path",
+				},
+				IncludeNamespaces: []string{"ns1", "ns2"},
+			},
+		})
+	}
+
+	withAliases := sink.NewConfig("127.0.0.1:5000", sink.WithSinkAliases(true))
+	upsertSinks(withAliases)
+	withoutAliases := sink.NewConfig("127.0.0.1:5000")
+	upsertSinks(withoutAliases)
+
+	config := withAliases.String()
+	for _, expected := range []string{
+		"\n[OUTPUT]\n    Name syslog\n    Alias syslog\n",
+		"\n[OUTPUT]\n    Name http\n    Alias ns_some-namespace_webhook-sink\n",
+		"\n[OUTPUT]\n    Name http\n    Alias cluster_cluster-webhook-sink\n",
+		"\n[OUTPUT]\n    Name http\n    Alias cluster_cluster-webhook-sink_1\n",
+	} {
+		if strings.Count(config, expected) != 1 {
+			t.Errorf("expected config to contain %q once, got:\n%s", expected, config)
+		}
+	}
+	if strings.Contains(withoutAliases.String(), "Alias") {
+		t.Errorf("expected no aliases by default, got:\n%s", withoutAliases.String())
+	}
+}
+
+func TestSinkComments(t *testing.T) {
+	upsertSinks := func(sc *sink.Config) {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "syslog-sink",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		})
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "webhook-sink",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://example.com/some/path",
+				},
+			},
+		})
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "cluster-webhook-sink",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://example.com/other/path",
+				},
+			},
+		})
+	}
+
+	withComments := sink.NewConfig("127.0.0.1:5000", sink.WithSinkComments(true))
+	upsertSinks(withComments)
+	withoutComments := sink.NewConfig("127.0.0.1:5000")
+	upsertSinks(withoutComments)
+
+	config := withComments.String()
+	for _, expected := range []string{
+		"\n# sink: some-namespace/syslog-sink\n[OUTPUT]\n    Name syslog\n",
+		"\n# sink: some-namespace/webhook-sink\n[OUTPUT]\n    Name http\n",
+		"\n# cluster sink: cluster-webhook-sink\n[OUTPUT]\n    Name http\n",
+	} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("expected config to contain %q, got:\n%s", expected, config)
+		}
+	}
+	if strings.Contains(withoutComments.String(), "#") {
+		t.Errorf("expected no comments by default, got:\n%s", withoutComments.String())
+	}
+
+	f, err := flbconfig.Parse("", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := flbconfig.Parse("", withoutComments.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(f, expected) {
+		t.Fatal(cmp.Diff(f, expected))
+	}
+}
+
+func TestAllowedHosts(t *testing.T) {
+	testCases := map[string]struct {
+		allowedHosts  []string
+		spec          v1alpha1.SinkSpec
+		expectedError string
+	}{
+		"allow all": {
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "anywhere.example.org",
+					Port: 514,
+				},
+			},
+		},
+		"allowed host": {
+			allowedHosts: []string{"logs.example.com"},
+			spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "Logs.example.com.",
+					Port: 514,
+				},
+			},
+		},
+		"rejected host": {
+			allowedHosts: []string{"logs.example.com"},
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://evil.example.org/some/path",
+				},
+			},
+			expectedError: "sink some-namespace/some-name: host evil.example.org is not allowed",
+		},
+		"suffix match": {
+			allowedHosts: []string{".example.com"},
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://tenant.logs.example.com/some/path",
+				},
+			},
+		},
+		"suffix does not match a partial label": {
+			allowedHosts: []string{".example.com"},
+			spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://notexample.com/some/path",
+				},
+			},
+			expectedError: "sink some-namespace/some-name: host notexample.com is not allowed",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000", sink.WithAllowedHosts(tc.allowedHosts...))
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: tc.spec,
+			})
+
+			errs := sc.Validate()
+			if tc.expectedError == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no errors, got: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Error() != tc.expectedError {
+				t.Fatalf("expected %q, got: %v", tc.expectedError, errs)
+			}
+		})
+	}
+}
+
+func TestSyslogReconnectBackoff(t *testing.T) {
+	testCases := map[string]struct {
+		backoffMs     int
+		maxMs         int
+		expectedKeys  []string
+		forbiddenKeys []string
+	}{
+		"not set": {
+			forbiddenKeys: []string{"reconnect_backoff_ms", "reconnect_max_ms"},
+		},
+		"negative": {
+			backoffMs:     -1,
+			maxMs:         -1,
+			forbiddenKeys: []string{"reconnect_backoff_ms", "reconnect_max_ms"},
+		},
+		"positive": {
+			backoffMs:    100,
+			maxMs:        30000,
+			expectedKeys: []string{`"reconnect_backoff_ms":100`, `"reconnect_max_ms":30000`},
+		},
+		"only backoff": {
+			backoffMs:     250,
+			expectedKeys:  []string{`"reconnect_backoff_ms":250`},
+			forbiddenKeys: []string{"reconnect_max_ms"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			spec := v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:               "example.com",
+					Port:               12345,
+					ReconnectBackoffMs: tc.backoffMs,
+					ReconnectMaxMs:     tc.maxMs,
+				},
+			}
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: spec,
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: spec,
+			})
+
+			config := sc.String()
+			for _, k := range tc.expectedKeys {
+				if strings.Count(config, k) != 2 {
+					t.Errorf("expected %s in both sink lists, got:\n%s", k, config)
+				}
+			}
+			for _, k := range tc.forbiddenKeys {
+				if strings.Contains(config, k) {
+					t.Errorf("expected no %s, got:\n%s", k, config)
+				}
+			}
+		})
+	}
+}
+
+func TestSyslogStructuredData(t *testing.T) {
+	spec := v1alpha1.SinkSpec{
+		Type: "syslog",
+		SyslogSpec: v1alpha1.SyslogSpec{
+			Host: "example.com",
+			Port: 12345,
+			StructuredData: map[string]string{
+				"pod@32473":       "$kubernetes['pod_name']",
+				"container@32473": "$kubernetes['container_name']",
+			},
+		},
+	}
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: spec,
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: spec,
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host:           "example.com",
+				Port:           12346,
+				StructuredData: map[string]string{},
+			},
+		},
+	})
+
+	config := sc.String()
+	expected := `"structured_data":{"container@32473":"$kubernetes['container_name']","pod@32473":"$kubernetes['pod_name']"}`
+	if strings.Count(config, expected) != 2 {
+		t.Errorf("expected sorted structured data in both sink lists, got:\n%s", config)
+	}
+	if strings.Count(config, "structured_data") != 2 {
+		t.Errorf("expected no structured data for the sink without any, got:\n%s", config)
+	}
+}
+
+func TestSyslogOutputsSplitByTLS(t *testing.T) {
+	plain := v1alpha1.SyslogSpec{
+		Host: "example.com",
+		Port: 12345,
+	}
+	secure := v1alpha1.SyslogSpec{
+		Host:      "example.com",
+		Port:      12346,
+		EnableTLS: true,
+	}
+	testCases := map[string]struct {
+		sink            v1alpha1.SyslogSpec
+		clusterSink     v1alpha1.SyslogSpec
+		expectedOutputs [][2]string
+	}{
+		"only plain": {
+			sink:        plain,
+			clusterSink: plain,
+			expectedOutputs: [][2]string{
+				{
+					`[{"addr":"example.com:12345","namespace":"some-namespace","name":"some-name"}]`,
+					`[{"addr":"example.com:12345","name":"some-cluster-name","exclude_namespaces":["kube-system","kube-public","kube-node-lease"]}]`,
+				},
+			},
+		},
+		"only tls": {
+			sink:        secure,
+			clusterSink: secure,
+			expectedOutputs: [][2]string{
+				{
+					`[{"addr":"example.com:12346","namespace":"some-namespace","tls":{},"name":"some-name"}]`,
+					`[{"addr":"example.com:12346","tls":{},"name":"some-cluster-name","exclude_namespaces":["kube-system","kube-public","kube-node-lease"]}]`,
+				},
+			},
+		},
+		"mixed": {
+			sink:        secure,
+			clusterSink: plain,
+			expectedOutputs: [][2]string{
+				{
+					`[]`,
+					`[{"addr":"example.com:12345","name":"some-cluster-name","exclude_namespaces":["kube-system","kube-public","kube-node-lease"]}]`,
+				},
+				{
+					`[{"addr":"example.com:12346","namespace":"some-namespace","tls":{},"name":"some-name"}]`,
+					`[]`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type:       "syslog",
+					SyslogSpec: tc.sink,
+				},
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type:       "syslog",
+					SyslogSpec: tc.clusterSink,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var outputs [][2]string
+			for _, s := range f.Sections {
+				kvs := make(map[string]string)
+				for _, kv := range s.KeyValues {
+					kvs[kv.Key] = kv.Value
+				}
+				if s.Name == "OUTPUT" && kvs["Name"] == "syslog" {
+					outputs = append(outputs, [2]string{kvs["Sinks"], kvs["ClusterSinks"]})
+				}
+			}
+			if diff := cmp.Diff(tc.expectedOutputs, outputs); diff != "" {
+				t.Errorf("syslog outputs not equal (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSyslogTransportAndFormat(t *testing.T) {
+	testCases := map[string]struct {
+		transport     string
+		format        string
+		expectedKeys  []string
+		forbiddenKeys []string
+	}{
+		"not set": {
+			forbiddenKeys: []string{`"transport"`, `"format"`},
+		},
+		"rfc5424": {
+			format:        "rfc5424",
+			expectedKeys:  []string{`"format":"rfc5424"`},
+			forbiddenKeys: []string{`"transport"`},
+		},
+		"rfc3164": {
+			format:        "rfc3164",
+			expectedKeys:  []string{`"format":"rfc3164"`},
+			forbiddenKeys: []string{`"transport"`},
+		},
+		"rfc3164 over udp": {
+			transport:     "udp",
+			format:        "rfc3164",
+			expectedKeys:  []string{`"transport":"udp"`, `"format":"rfc3164"`},
+			forbiddenKeys: []string{`"tls"`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			spec := v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:         "example.com",
+					Port:         12345,
+					Transport:    tc.transport,
+					SyslogFormat: tc.format,
+				},
+			}
+			sc := sink.NewConfig("127.0.0.1:5000", sink.WithDefaultEnableTLS(true))
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: spec,
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: spec,
+			})
+
+			config := sc.String()
+			for _, k := range tc.expectedKeys {
+				if strings.Count(config, k) != 2 {
+					t.Errorf("expected %s in both sink lists, got:\n%s", k, config)
+				}
+			}
+			for _, k := range tc.forbiddenKeys {
+				if strings.Contains(config, k) {
+					t.Errorf("expected no %s, got:\n%s", k, config)
+				}
+			}
+
+			expectedProtocol := "tls"
+			if tc.transport == "udp" {
+				expectedProtocol = "udp"
+			}
+			for _, d := range sc.Destinations() {
+				if d.Protocol != expectedProtocol {
+					t.Errorf("expected protocol %s, got %s", expectedProtocol, d.Protocol)
+				}
+			}
+		})
+	}
+}
+
+func TestSyslogMessageKey(t *testing.T) {
+	testCases := map[string]struct {
+		messageKey string
+		expected   string
+	}{
+		"not set": {},
+		"log": {
+			messageKey: "log",
+			expected:   `"message_key":"log"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			spec := v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:       "example.com",
+					Port:       12345,
+					MessageKey: tc.messageKey,
+				},
+			}
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: spec,
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: spec,
+			})
+
+			config := sc.String()
+			if tc.expected == "" {
+				if strings.Contains(config, "message_key") {
+					t.Errorf("expected no message_key, got:\n%s", config)
+				}
+				return
+			}
+			if strings.Count(config, tc.expected) != 2 {
+				t.Errorf("expected %s in both sink lists, got:\n%s", tc.expected, config)
+			}
+		})
+	}
+}
+
+func TestSyslogTLSFiles(t *testing.T) {
+	testCases := map[string]struct {
+		enableTLS bool
+		expected  string
+	}{
+		"tls": {
+			enableTLS: true,
+			expected:  `"tls":{"ca_file":"/etc/tls/ca.crt","cert_file":"/etc/tls/tls.crt","key_file":"/etc/tls/tls.key"}`,
+		},
+		"no tls": {},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			spec := v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:      "example.com",
+					Port:      12345,
+					EnableTLS: tc.enableTLS,
+					CAFile:    "/etc/tls/ca.crt",
+					CertFile:  "/etc/tls/tls.crt",
+					KeyFile:   "/etc/tls/tls.key",
+				},
+			}
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: spec,
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: spec,
+			})
+
+			config := sc.String()
+			if tc.expected == "" {
+				if strings.Contains(config, "_file") {
+					t.Errorf("expected no TLS files, got:\n%s", config)
+				}
+				return
+			}
+			if strings.Count(config, tc.expected) != 2 {
+				t.Errorf("expected %s in both sink lists, got:\n%s", tc.expected, config)
+			}
+
+			sinks, clusterSinks, err := sink.ParseConfig(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sinks) != 1 || len(clusterSinks) != 1 {
+				t.Fatalf("expected a sink and a cluster sink, got %d and %d", len(sinks), len(clusterSinks))
+			}
+			if diff := cmp.Diff(spec.SyslogSpec, sinks[0].Spec.SyslogSpec); diff != "" {
+				t.Errorf("parsed spec not equal (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestYAMLFormat(t *testing.T) {
+	sinks := []*v1alpha1.LogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-syslog-name",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+				SampleRate: 10,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-otlp-name",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "otlp",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 4318,
+				},
+				OTLPSpec: v1alpha1.OTLPSpec{
+					Headers: map[string]string{
+						"X-A": "a",
+						"X-B": "b",
+					},
+				},
+			},
+		},
+	}
+	classic := sink.NewConfig("127.0.0.1:5000")
+	classic.ReplaceAll(sinks, nil)
+	structured := sink.NewConfig("127.0.0.1:5000", sink.WithFormat(sink.YAMLFormat))
+	structured.ReplaceAll(sinks, nil)
+
+	config := structured.String()
+	if config != structured.String() {
+		t.Fatal("expected the YAML config to be deterministic")
+	}
+	checked, err := structured.RenderChecked()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked != config {
+		t.Fatalf("expected RenderChecked to render the YAML config, got:\n%s", checked)
+	}
+
+	var parsed struct {
+		Pipeline map[string][]map[string]interface{} `json:"pipeline"`
+	}
+	if err := yaml.UnmarshalStrict([]byte(config), &parsed); err != nil {
+		t.Fatalf("unable to parse YAML config: %s\n%s", err, config)
+	}
+
+	f, err := flbconfig.Parse("", classic.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputs, filters []flbconfig.Section
+	for _, s := range f.Sections {
+		switch s.Name {
+		case "OUTPUT":
+			outputs = append(outputs, s)
+		case "FILTER":
+			filters = append(filters, s)
+		}
+	}
+	if len(parsed.Pipeline) != 2 {
+		t.Fatalf("expected filters and outputs, got: %v", parsed.Pipeline)
+	}
+	for kind, sections := range map[string][]flbconfig.Section{"outputs": outputs, "filters": filters} {
+		actual := parsed.Pipeline[kind]
+		if len(actual) != len(sections) {
+			t.Fatalf("expected %d %s, got %d", len(sections), kind, len(actual))
+		}
+		for i, s := range sections {
+			for _, kv := range s.KeyValues {
+				v := actual[i][strings.ToLower(kv.Key)]
+				if values, ok := v.([]interface{}); ok {
+					if !containsValue(values, kv.Value) {
+						t.Errorf("expected %s %d %s to contain %q, got: %v", kind, i, kv.Key, kv.Value, values)
+					}
+					continue
+				}
+				if v != kv.Value {
+					t.Errorf("expected %s %d %s to be %q, got: %v", kind, i, kv.Key, kv.Value, v)
+				}
+			}
+		}
+	}
+	if headers, ok := parsed.Pipeline["outputs"][1]["header"].([]interface{}); !ok || len(headers) != 2 {
+		t.Errorf("expected the otlp headers as a list, got: %v", parsed.Pipeline["outputs"][1]["header"])
+	}
+}
+
+func containsValue(values []interface{}, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSyslogEmptyHost(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Port: 514,
+			},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Port: 514,
+			},
+		},
+	})
+
+	config := sc.String()
+	if strings.Contains(config, ":514") {
+		t.Errorf("expected empty host sinks to be excluded, got:\n%s", config)
+	}
+	if !strings.Contains(config, "Name null") {
+		t.Errorf("expected the null output, got:\n%s", config)
+	}
+	if !sc.IsEmpty() {
+		t.Error("expected config with only empty host sinks to be empty")
+	}
+
+	errs := sc.Validate()
+	expected := []string{
+		"cluster sink some-cluster-name: host must not be empty",
+		"sink some-namespace/some-name: host must not be empty",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got: %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], err)
+		}
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	s := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	}
+	updated := s.DeepCopy()
+	updated.Spec.Port = 12346
+	cs := &v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: s.Spec,
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000")
+	var calls int
+	sc.OnChange(func() {
+		// The config must not be locked while callbacks run.
+		_ = sc.String()
+		calls++
+	})
+
+	steps := []struct {
+		name    string
+		mutate  func()
+		changed bool
+	}{
+		{"upsert new sink", func() { sc.UpsertSink(s) }, true},
+		{"upsert identical sink", func() { sc.UpsertSink(s.DeepCopy()) }, false},
+		{"upsert updated sink", func() { sc.UpsertSink(updated) }, true},
+		{"upsert new cluster sink", func() { sc.UpsertClusterSink(cs) }, true},
+		{"upsert identical cluster sink", func() { sc.UpsertClusterSink(cs.DeepCopy()) }, false},
+		{"replace with identical sinks", func() {
+			sc.ReplaceAll([]*v1alpha1.LogSink{updated}, []*v1alpha1.ClusterLogSink{cs})
+		}, false},
+		{"apply identical sinks", func() {
+			sc.Apply([]*v1alpha1.LogSink{updated}, []*v1alpha1.ClusterLogSink{cs})
+		}, false},
+		{"apply removing cluster sink", func() {
+			sc.Apply([]*v1alpha1.LogSink{updated}, nil)
+		}, true},
+		{"delete sink", func() { sc.DeleteSink(s) }, true},
+		{"delete missing sink", func() { sc.DeleteSink(s) }, false},
+		{"delete missing cluster sink", func() { sc.DeleteClusterSink(cs) }, false},
+		{"reset empty config", func() { sc.Reset() }, false},
+		{"replace with new sinks", func() {
+			sc.ReplaceAll([]*v1alpha1.LogSink{s}, nil)
+		}, true},
+		{"reset", func() { sc.Reset() }, true},
+	}
+	for _, step := range steps {
+		before := calls
+		step.mutate()
+		expected := before
+		if step.changed {
+			expected++
+		}
+		if calls != expected {
+			t.Errorf("%s: expected %d calls, got %d", step.name, expected, calls)
+		}
+	}
+}
+
+func TestSinkTemplates(t *testing.T) {
+	template := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "baseline-{{.Namespace}}",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "{{.Namespace}}.logs.example.com",
+				Port: 514,
+			},
+		},
+	}
+	webhookTemplate := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "baseline-webhook",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/{{.Namespace}}",
+			},
+		},
+	}
+	sc := sink.NewConfig(
+		"127.0.0.1:5000",
+		sink.WithSinkTemplates(template, webhookTemplate),
+		sink.WithMatchTemplate("kube.{{.Namespace}}.*"),
+	)
+	var calls int
+	sc.OnChange(func() { calls++ })
+
+	sc.AddNamespace("ns-a")
+	sc.AddNamespace("ns-a")
+	sc.AddNamespace("ns-b")
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+	// A sink in the namespace with the name of the templated sink takes
+	// precedence over it.
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "baseline-ns-b",
+			Namespace: "ns-b",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "override.example.com",
+				Port: 514,
+			},
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Addr:      "ns-a.logs.example.com:514",
+				Namespace: "ns-a",
+				Name:      "baseline-ns-a",
+			},
+			{
+				Addr:      "override.example.com:514",
+				Namespace: "ns-b",
+				Name:      "baseline-ns-b",
+			},
+		},
+		[]clusterSink{},
+		httpOutputSection("kube.ns-a.*", "example.com", "80", "/ns-a"),
+		httpOutputSection("kube.ns-b.*", "example.com", "80", "/ns-b"),
+	)
+	if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+
+	sc.DeleteNamespace("ns-a")
+	if calls != 4 {
+		t.Errorf("expected 4 calls, got %d", calls)
+	}
+	if strings.Contains(sc.String(), "ns-a") {
+		t.Errorf("expected no sink for ns-a, got:\n%s", sc.String())
+	}
+}
+
+func TestMaxSinks(t *testing.T) {
+	newSink := func(name string) *v1alpha1.LogSink {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		}
+	}
+	cs := &v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: newSink("").Spec,
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		for i := 0; i < 100; i++ {
+			if err := sc.UpsertSinkChecked(newSink(fmt.Sprintf("sink-%d", i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000", sink.WithMaxSinks(2))
+		if err := sc.UpsertSinkChecked(newSink("sink-0")); err != nil {
+			t.Fatal(err)
+		}
+		if err := sc.UpsertClusterSinkChecked(cs); err != nil {
+			t.Fatalf("expected the limit to be reached, got: %s", err)
+		}
+		if err := sc.UpsertSinkChecked(newSink("sink-1")); err != sink.ErrMaxSinks {
+			t.Fatalf("expected ErrMaxSinks, got: %v", err)
+		}
+		otherCS := cs.DeepCopy()
+		otherCS.Name = "other-cluster-name"
+		if err := sc.UpsertClusterSinkChecked(otherCS); err != sink.ErrMaxSinks {
+			t.Fatalf("expected ErrMaxSinks, got: %v", err)
+		}
+		sc.UpsertSink(newSink("sink-1"))
+		if _, ok := sc.GetSink("some-namespace", "sink-1"); ok {
+			t.Fatal("expected sink over the limit not to be tracked")
+		}
+
+		updated := newSink("sink-0")
+		updated.Spec.Port = 12346
+		if err := sc.UpsertSinkChecked(updated); err != nil {
+			t.Fatalf("expected tracked sink to be updated at the limit, got: %s", err)
+		}
+
+		sc.DeleteSink(updated)
+		if err := sc.UpsertSinkChecked(newSink("sink-1")); err != nil {
+			t.Fatalf("expected sink to be tracked after a delete, got: %s", err)
+		}
+	})
+
+	t.Run("replace all", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000", sink.WithMaxSinks(2))
+		if err := sc.ReplaceAll([]*v1alpha1.LogSink{newSink("sink-0")}, []*v1alpha1.ClusterLogSink{cs}); err != nil {
+			t.Fatalf("expected the limit to be reached, got: %s", err)
+		}
+		before := sc.String()
+
+		err := sc.ReplaceAll(
+			[]*v1alpha1.LogSink{newSink("sink-0"), newSink("sink-1")},
+			[]*v1alpha1.ClusterLogSink{cs},
+		)
+		if err != sink.ErrMaxSinks {
+			t.Fatalf("expected ErrMaxSinks, got: %v", err)
+		}
+		if sc.String() != before {
+			t.Fatalf("expected sinks over the limit not to be tracked: %s", sc.String())
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000", sink.WithMaxSinks(2))
+		if _, err := sc.Apply([]*v1alpha1.LogSink{newSink("sink-0")}, []*v1alpha1.ClusterLogSink{cs}); err != nil {
+			t.Fatalf("expected the limit to be reached, got: %s", err)
+		}
+		before := sc.String()
+
+		_, err := sc.Apply([]*v1alpha1.LogSink{newSink("sink-0"), newSink("sink-1")}, []*v1alpha1.ClusterLogSink{cs})
+		if err != sink.ErrMaxSinks {
+			t.Fatalf("expected ErrMaxSinks, got: %v", err)
+		}
+		_, _, err = sc.ApplyAndRender([]*v1alpha1.LogSink{newSink("sink-0"), newSink("sink-1")}, []*v1alpha1.ClusterLogSink{cs})
+		if err != sink.ErrMaxSinks {
+			t.Fatalf("expected ErrMaxSinks, got: %v", err)
+		}
+		if sc.String() != before {
+			t.Fatalf("expected sinks over the limit not to be tracked: %s", sc.String())
+		}
+
+		if _, err := sc.Apply([]*v1alpha1.LogSink{newSink("sink-0"), newSink("sink-1")}, nil); err != nil {
+			t.Fatalf("expected sinks replacing removed ones to be tracked, got: %s", err)
+		}
+	})
+
+	t.Run("new config with sinks", func(t *testing.T) {
+		_, err := sink.NewConfigWithSinks(
+			"127.0.0.1:5000",
+			[]*v1alpha1.LogSink{newSink("sink-0")},
+			[]*v1alpha1.ClusterLogSink{cs},
+			sink.WithMaxSinks(2),
+		)
+		if err != nil {
+			t.Fatalf("expected the limit to be reached, got: %s", err)
+		}
+
+		_, err = sink.NewConfigWithSinks(
+			"127.0.0.1:5000",
+			[]*v1alpha1.LogSink{newSink("sink-0"), newSink("sink-1")},
+			[]*v1alpha1.ClusterLogSink{cs},
+			sink.WithMaxSinks(2),
+		)
+		if err != sink.ErrMaxSinks {
+			t.Fatalf("expected ErrMaxSinks, got: %v", err)
+		}
+	})
+}
+
+func TestHealthOutput(t *testing.T) {
+	heartbeat := httpOutputSection("heartbeat", "monitoring.example.com", "443", "/heartbeat")
+	heartbeat.KeyValues = append(heartbeat.KeyValues, flbconfig.KeyValue{Key: "tls", Value: "On"})
+
+	t.Run("without sinks", func(t *testing.T) {
+		sc := sink.NewConfig(
+			"127.0.0.1:5000",
+			sink.WithHealthOutput("heartbeat", "https://monitoring.example.com/heartbeat"),
+		)
+
+		f, err := flbconfig.Parse("", sc.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := flbconfig.Parse("", emptyConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected.Sections = append(expected.Sections, heartbeat)
+		if !cmp.Equal(f, expected, compareFLBConfig) {
+			t.Fatal(cmp.Diff(f, expected))
+		}
+	})
+
+	t.Run("with sinks", func(t *testing.T) {
+		sc := sink.NewConfig(
+			"127.0.0.1:5000",
+			sink.WithHealthOutput("heartbeat", "https://monitoring.example.com/heartbeat"),
+		)
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-name",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+			},
+		})
+
+		f, err := flbconfig.Parse("", sc.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := sinksToConfigAST(
+			t,
+			[]namespaceSink{
+				{
+					Addr:      "example.com:12345",
+					Namespace: "some-namespace",
+					Name:      "some-name",
+				},
+			},
+			[]clusterSink{},
+			heartbeat,
+		)
+		if !cmp.Equal(f, expected, compareFLBConfig) {
+			t.Fatal(cmp.Diff(f, expected))
+		}
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		sc := sink.NewConfig("127.0.0.1:5000", sink.WithHealthOutput("heartbeat", "://"))
+		if _, err := sc.RenderChecked(); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestRedact(t *testing.T) {
+	testCases := map[string]struct {
+		redact           []string
+		isolated         bool
+		expectedSections []flbconfig.Section
+	}{
+		"no redaction": {},
+		"two patterns": {
+			redact:   []string{"token", "^password$", "token"},
+			isolated: true,
+			expectedSections: []flbconfig.Section{
+				isolateFilterSection("*_some-namespace_*", "sink.ns.some-namespace.some-name", "sink_ns_some-namespace_some-name"),
+				{
+					Name: "FILTER",
+					KeyValues: []flbconfig.KeyValue{
+						{Key: "Name", Value: "modify"},
+						{Key: "Match", Value: "sink.ns.some-namespace.some-name"},
+						{Key: "Remove_regex", Value: "^password$"},
+						{Key: "Remove_regex", Value: "token"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "syslog",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						Host: "example.com",
+						Port: 12345,
+					},
+					Redact: tc.redact,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := namespaceSink{
+				Addr:      "example.com:12345",
+				Namespace: "some-namespace",
+				Name:      "some-name",
+			}
+			if tc.isolated {
+				expected.Matches = []string{"sink.ns.some-namespace.some-name"}
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{expected},
+				[]clusterSink{},
+				tc.expectedSections...,
+			)
+			if tc.isolated {
+				expectedConfig = withSyslogMatch(expectedConfig, "sink.*")
+			}
+			if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestStripKubernetesMetadata(t *testing.T) {
+	testCases := map[string]struct {
+		strip            bool
+		redact           []string
+		expectedMatch    string
+		isolateSections  []flbconfig.Section
+		expectedSections []flbconfig.Section
+	}{
+		"kept by default": {
+			expectedMatch: "*_some-namespace_*",
+		},
+		"stripped": {
+			strip:         true,
+			expectedMatch: "sink.ns.some-namespace.some-name",
+			isolateSections: []flbconfig.Section{
+				isolateFilterSection("*_some-namespace_*", "sink.ns.some-namespace.some-name", "sink_ns_some-namespace_some-name"),
+			},
+			expectedSections: []flbconfig.Section{
+				{
+					Name: "FILTER",
+					KeyValues: []flbconfig.KeyValue{
+						{Key: "Name", Value: "modify"},
+						{Key: "Match", Value: "sink.ns.some-namespace.some-name"},
+						{Key: "Remove", Value: "kubernetes"},
+					},
+				},
+			},
+		},
+		"stripped and redacted": {
+			strip:         true,
+			redact:        []string{"token"},
+			expectedMatch: "sink.ns.some-namespace.some-name",
+			isolateSections: []flbconfig.Section{
+				isolateFilterSection("*_some-namespace_*", "sink.ns.some-namespace.some-name", "sink_ns_some-namespace_some-name"),
+			},
+			expectedSections: []flbconfig.Section{
+				{
+					Name: "FILTER",
+					KeyValues: []flbconfig.KeyValue{
+						{Key: "Name", Value: "modify"},
+						{Key: "Match", Value: "sink.ns.some-namespace.some-name"},
+						{Key: "Remove", Value: "kubernetes"},
+						{Key: "Remove_regex", Value: "token"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					Redact:                  tc.redact,
+					StripKubernetesMetadata: tc.strip,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sections := append(append(tc.isolateSections,
+				httpOutputSection(
+					tc.expectedMatch,
+					"example.com",
+					"80",
+					"/some/path",
+				),
+			), tc.expectedSections...)
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				sections...,
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestStripKubernetesMetadataKeepsOtherSinks(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+			StripKubernetesMetadata: true,
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+			IncludeNamespaces: []string{"ns1"},
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range f.Sections {
+		if len(s.KeyValues) < 2 {
+			continue
+		}
+		name, match := s.KeyValues[0].Value, s.KeyValues[1]
+		switch name {
+		case "modify":
+			if match.Value != "sink.ns.ns1.some-name" {
+				t.Errorf("expected the modify filter to match the records of the sink only, got %s %s", match.Key, match.Value)
+			}
+		case "syslog":
+			if match.Key != "Match_Regex" || match.Value != `^(?!sink\.).*$` {
+				t.Errorf("expected the syslog output to leave out isolated records, got %s %s", match.Key, match.Value)
+			}
+		}
+	}
+}
+
+func TestParser(t *testing.T) {
+	testCases := map[string]struct {
+		parser          string
+		expectedMatch   string
+		expectedSection *flbconfig.Section
+	}{
+		"no parser": {
+			expectedMatch: "*_some-namespace_*",
+		},
+		"parser": {
+			parser:        "json",
+			expectedMatch: "sink.ns.some-namespace.some-name",
+			expectedSection: &flbconfig.Section{
+				Name: "FILTER",
+				KeyValues: []flbconfig.KeyValue{
+					{Key: "Name", Value: "parser"},
+					{Key: "Match", Value: "sink.ns.some-namespace.some-name"},
+					{Key: "Key_Name", Value: "log"},
+					{Key: "Parser", Value: "json"},
+					{Key: "Reserve_Data", Value: "On"},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					Parser: tc.parser,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sections []flbconfig.Section
+			if tc.expectedSection != nil {
+				sections = append(sections,
+					isolateFilterSection("*_some-namespace_*", "sink.ns.some-namespace.some-name", "sink_ns_some-namespace_some-name"),
+					*tc.expectedSection,
+				)
+			}
+			sections = append(sections, httpOutputSection(
+				tc.expectedMatch,
+				"example.com",
+				"80",
+				"/some/path",
+			))
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{},
+				sections...,
+			)
+			if !cmp.Equal(f, expectedConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestTailSource(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-webhook-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+			TailSource: &v1alpha1.SourceSpec{
+				Path: "/var/log/app/*.log",
+				Tag:  "app.logs",
+			},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-syslog-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+			TailSource: &v1alpha1.SourceSpec{
+				Path: "/var/log/audit.log",
+				Tag:  "audit",
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/other/path",
+			},
+			TailSource: &v1alpha1.SourceSpec{
+				Path: "/etc/shadow",
+				Tag:  "ignored",
+			},
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{},
+		[]clusterSink{
+			{
+				Addr:    "example.com:12345",
+				Name:    "some-syslog-name",
+				Matches: []string{"audit"},
+			},
+		},
+		flbconfig.Section{
+			Name: "INPUT",
+			KeyValues: []flbconfig.KeyValue{
+				{Key: "Name", Value: "tail"},
+				{Key: "Path", Value: "/var/log/audit.log"},
+				{Key: "Tag", Value: "audit"},
+			},
+		},
+		flbconfig.Section{
+			Name: "INPUT",
+			KeyValues: []flbconfig.KeyValue{
+				{Key: "Name", Value: "tail"},
+				{Key: "Path", Value: "/var/log/app/*.log"},
+				{Key: "Tag", Value: "app.logs"},
+			},
+		},
+	)
+	expectedConfig.Sections = append(
+		expectedConfig.Sections,
+		httpOutputSection(
+			"*_some-namespace_*",
+			"example.com",
+			"80",
+			"/other/path",
+		),
+		httpOutputSection(
+			"app.logs",
+			"example.com",
+			"80",
+			"/some/path",
+		),
+	)
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestIncludeSystemNamespaces(t *testing.T) {
+	testCases := map[string]struct {
+		include         bool
+		expectedExclude []string
+		expectedMatch   string
+	}{
+		"excluded by default": {
+			expectedExclude: sink.SystemNamespaces,
+			expectedMatch:   systemNamespacesExcluded,
+		},
+		"included": {
+			include:       true,
+			expectedMatch: "*",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-syslog-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "syslog",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						Host: "example.com",
+						Port: 12345,
+					},
+					IncludeSystemNamespaces: tc.include,
+				},
+			})
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-webhook-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "webhook",
+					WebhookSpec: v1alpha1.WebhookSpec{
+						URL: "http://example.com/some/path",
+					},
+					IncludeSystemNamespaces: tc.include,
+				},
+			})
+
+			f, err := flbconfig.Parse("", sc.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedConfig := sinksToConfigAST(
+				t,
+				[]namespaceSink{},
+				[]clusterSink{
+					{
+						Addr:              "example.com:12345",
+						Name:              "some-syslog-name",
+						ExcludeNamespaces: tc.expectedExclude,
+					},
+				},
+				httpOutputSection(tc.expectedMatch, "example.com", "80", "/some/path"),
+			)
+			if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+				t.Fatal(cmp.Diff(f, expectedConfig))
+			}
+		})
+	}
+}
+
+func TestCoveredNamespaces(t *testing.T) {
+	syslogSpec := v1alpha1.SinkSpec{
+		Type: "syslog",
+		SyslogSpec: v1alpha1.SyslogSpec{
+			Host: "example.com",
+			Port: 12345,
+		},
+	}
+	disabledSpec := syslogSpec
+	disabledSpec.Disabled = true
+	limitedSpec := syslogSpec
+	limitedSpec.IncludeNamespaces = []string{"ns-c", "ns-a"}
+
+	sc := sink.NewConfig("127.0.0.1:5000")
+	if namespaces := sc.CoveredNamespaces(); len(namespaces) != 0 {
+		t.Fatalf("expected no namespaces, got: %v", namespaces)
+	}
+
+	for _, s := range []struct {
+		name      string
+		namespace string
+		spec      v1alpha1.SinkSpec
+	}{
+		{"sink-0", "ns-b", syslogSpec},
+		{"sink-1", "ns-a", syslogSpec},
+		{"sink-2", "ns-a", syslogSpec},
+		{"sink-3", "ns-d", disabledSpec},
+	} {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.name,
+				Namespace: s.namespace,
+			},
+			Spec: s.spec,
+		})
+	}
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "limited",
+		},
+		Spec: limitedSpec,
+	})
+
+	expected := []string{"ns-a", "ns-b", "ns-c"}
+	if namespaces := sc.CoveredNamespaces(); !cmp.Equal(namespaces, expected) {
+		t.Fatal(cmp.Diff(namespaces, expected))
+	}
+
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-wide",
+		},
+		Spec: syslogSpec,
+	})
+	expected = []string{sink.AllNamespaces, "ns-a", "ns-b", "ns-c"}
+	if namespaces := sc.CoveredNamespaces(); !cmp.Equal(namespaces, expected) {
+		t.Fatal(cmp.Diff(namespaces, expected))
+	}
+}
+
+func TestMatches(t *testing.T) {
+	matches := []string{"*_some-namespace_nginx-*", "*_some-namespace_redis-*"}
+	for _, sinkType := range []string{"syslog", "webhook"} {
+		t.Run(sinkType, func(t *testing.T) {
+			spec := v1alpha1.SinkSpec{
+				Type:    sinkType,
+				Matches: matches,
+			}
+			if sinkType == "syslog" {
+				spec.SyslogSpec = v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				}
+			} else {
+				spec.WebhookSpec = v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				}
+			}
+			sc := sink.NewConfig("127.0.0.1:5000")
+			sc.UpsertSink(&v1alpha1.LogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-name",
+					Namespace: "some-namespace",
+				},
+				Spec: spec,
+			})
+			spec.Matches = []string{"*_ns1_*", "*_ns2_*"}
+			spec.IncludeNamespaces = []string{"ns3"}
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: spec,
+			})
+
+			config := sc.String()
+			var expected []string
+			if sinkType == "syslog" {
+				expected = []string{
+					`"matches":["*_some-namespace_nginx-*","*_some-namespace_redis-*"]`,
+					`"matches":["*_ns1_*","*_ns2_*"]`,
+				}
+			} else {
+				expected = []string{
+					"Match *_some-namespace_nginx-*\n",
+					"Match *_some-namespace_redis-*\n",
+					"Match *_ns1_*\n",
+					"Match *_ns2_*\n",
+				}
+			}
+			for _, e := range expected {
+				if !strings.Contains(config, e) {
+					t.Errorf("expected config to contain %q, got:\n%s", e, config)
+				}
+			}
+			for _, forbidden := range []string{"*_some-namespace_*", "ns3"} {
+				if strings.Contains(config, forbidden) {
+					t.Errorf("expected config not to contain %q, got:\n%s", forbidden, config)
+				}
+			}
+			if errs := sc.Validate(); len(errs) != 0 {
+				t.Errorf("expected the matches to be valid, got: %v", errs)
+			}
+		})
+	}
+}
+
+func TestOverlappingSinks(t *testing.T) {
+	syslogSink := func(namespace, name string, matches ...string) *v1alpha1.LogSink {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12345,
+				},
+				Matches: matches,
+			},
+		}
+	}
+	testCases := map[string]struct {
+		sinks    []*v1alpha1.LogSink
+		expected [][]sink.SinkRef
+	}{
+		"different namespaces": {
+			sinks: []*v1alpha1.LogSink{
+				syslogSink("ns-a", "sink-a"),
+				syslogSink("ns-b", "sink-b"),
+			},
+		},
+		"same namespace": {
+			sinks: []*v1alpha1.LogSink{
+				syslogSink("ns-a", "sink-b"),
+				syslogSink("ns-a", "sink-a"),
+				syslogSink("ns-b", "sink-c"),
+				syslogSink("ns-b", "sink-d"),
+				syslogSink("ns-c", "sink-e"),
+			},
+			expected: [][]sink.SinkRef{
+				{{Namespace: "ns-a", Name: "sink-a"}, {Namespace: "ns-a", Name: "sink-b"}},
+				{{Namespace: "ns-b", Name: "sink-c"}, {Namespace: "ns-b", Name: "sink-d"}},
+			},
+		},
+		"disjoint matches": {
+			sinks: []*v1alpha1.LogSink{
+				syslogSink("ns-a", "sink-a", "*_ns-a_app-a-*"),
+				syslogSink("ns-a", "sink-b", "*_ns-a_app-b-*"),
+			},
+		},
+		"overlapping matches": {
+			sinks: []*v1alpha1.LogSink{
+				syslogSink("ns-a", "sink-a", "*_ns-a_app-*"),
+				syslogSink("ns-a", "sink-b", "*_ns-a_*-web"),
+			},
+			expected: [][]sink.SinkRef{
+				{{Namespace: "ns-a", Name: "sink-a"}, {Namespace: "ns-a", Name: "sink-b"}},
+			},
+		},
+		"matches within the namespace of a sink without matches": {
+			sinks: []*v1alpha1.LogSink{
+				syslogSink("ns-a", "sink-a", "*_ns-a_app-a-*"),
+				syslogSink("ns-a", "sink-b", "*_ns-a_app-b-*"),
+				syslogSink("ns-a", "sink-c"),
+			},
+			expected: [][]sink.SinkRef{
+				{
+					{Namespace: "ns-a", Name: "sink-a"},
+					{Namespace: "ns-a", Name: "sink-b"},
+					{Namespace: "ns-a", Name: "sink-c"},
+				},
+			},
+		},
+		"disabled sink": {
+			sinks: []*v1alpha1.LogSink{
+				syslogSink("ns-a", "sink-a"),
+				func() *v1alpha1.LogSink {
+					s := syslogSink("ns-a", "sink-b")
+					s.Spec.Disabled = true
+					return s
+				}(),
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc := sink.NewConfig("127.0.0.1:5000")
+			for _, s := range tc.sinks {
+				sc.UpsertSink(s)
+			}
+			sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "some-cluster-name",
+				},
+				Spec: v1alpha1.SinkSpec{
+					Type: "syslog",
+					SyslogSpec: v1alpha1.SyslogSpec{
+						Host: "example.com",
+						Port: 12346,
+					},
+				},
+			})
+
+			if diff := cmp.Diff(tc.expected, sc.OverlappingSinks()); diff != "" {
+				t.Errorf("OverlappingSinks not equal (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatchesOutsideNamespace(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "https://example.com/some/path",
+			},
+			Matches: []string{"*_kube-system_*"},
+		},
+	})
+
+	config := sc.String()
+	if strings.Contains(config, "kube-system") || !strings.Contains(config, "Match *_some-namespace_*\n") {
+		t.Errorf("expected the sink to fall back to its namespace, got:\n%s", config)
+	}
+	errs := sc.Validate()
+	expected := "sink some-namespace/some-name: match *_kube-system_* is outside of the namespace"
+	if len(errs) != 1 || errs[0].Error() != expected {
+		t.Errorf("expected error %q, got: %v", expected, errs)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	if summary, expected := sc.Summary(), "0 cluster sinks -> []"; summary != expected {
+		t.Errorf("expected summary %q, got %q", expected, summary)
+	}
+
+	for i, host := range []string{"collector", "collector", "other-collector"} {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("syslog-%d", i),
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: host,
+					Port: 514,
+				},
+			},
+		})
+	}
+	for _, disabled := range []bool{false, true} {
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("webhook-%t", disabled),
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+				Disabled: disabled,
+			},
+		})
+	}
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "collector",
+				Port: 514,
+			},
+		},
+	})
+	if summary, expected := sc.Summary(), "3 syslog, 1 webhook, 1 cluster sink -> [collector:514, example.com:443, other-collector:514]"; summary != expected {
+		t.Errorf("expected summary %q, got %q", expected, summary)
+	}
+
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "other-cluster-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://[::1]:8080/some/path",
+			},
+		},
+	})
+	if summary, expected := sc.Summary(), "3 syslog, 1 webhook, 2 cluster sinks -> [[::1]:8080, collector:514, example.com:443, other-collector:514]"; summary != expected {
+		t.Errorf("expected summary %q, got %q", expected, summary)
+	}
+}
+
+func TestHashWithSinkVersions(t *testing.T) {
+	newConfig := func() *sink.Config {
+		sc := sink.NewConfig("127.0.0.1:5000")
+		sc.UpsertSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-name",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:      "example.com",
+					Port:      12345,
+					EnableTLS: true,
+				},
+			},
+		})
+		sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "some-cluster-name",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+			},
+		})
+		return sc
+	}
+
+	sc := newConfig()
+	initial := sc.Hash()
+	if initial != newConfig().Hash() {
+		t.Fatal("expected the hash of identical configs to be equal")
+	}
+
+	sc.SetSinkVersion("some-namespace", "other-name", "1")
+	if sc.Hash() != initial {
+		t.Error("expected the version of an untracked sink not to change the hash")
+	}
+
+	config := sc.String()
+	sc.SetSinkVersion("some-namespace", "some-name", "1")
+	withVersion := sc.Hash()
+	if withVersion == initial {
+		t.Error("expected the version of a sink to change the hash")
+	}
+	sc.SetSinkVersion("some-namespace", "some-name", "2")
+	if h := sc.Hash(); h == withVersion || h == initial {
+		t.Error("expected a new version of a sink to change the hash")
+	}
+	if sc.String() != config {
+		t.Errorf("expected the version not to change the config, got:\n%s", sc.String())
+	}
+
+	sc.ClearSinkVersion("some-namespace", "some-name")
+	if sc.Hash() != initial {
+		t.Error("expected clearing the version to restore the hash")
+	}
+
+	sc.SetSinkVersion("", "some-cluster-name", "1")
+	if sc.Hash() == initial {
+		t.Error("expected the version of a cluster sink to change the hash")
+	}
+}
+
+func TestNewConfigChecked(t *testing.T) {
+	testCases := map[string]struct {
+		statsAddr   string
+		expectedErr bool
+	}{
+		"ipv4":       {statsAddr: "127.0.0.1:5000"},
+		"ipv6":       {statsAddr: "[::1]:5000"},
+		"hostname":   {statsAddr: "localhost:5000"},
+		"empty host": {statsAddr: ":5000"},
+		"empty": {
+			statsAddr:   "",
+			expectedErr: true,
+		},
+		"no port": {
+			statsAddr:   "127.0.0.1",
+			expectedErr: true,
+		},
+		"named port": {
+			statsAddr:   "127.0.0.1:http",
+			expectedErr: true,
+		},
+		"zero port": {
+			statsAddr:   "127.0.0.1:0",
+			expectedErr: true,
+		},
+		"port out of range": {
+			statsAddr:   "127.0.0.1:65536",
+			expectedErr: true,
+		},
+		"unbracketed ipv6": {
+			statsAddr:   "::1:5000",
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sc, err := sink.NewConfigChecked(tc.statsAddr)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q", tc.statsAddr)
+				}
+				if sc != nil {
+					t.Errorf("expected no config for %q", tc.statsAddr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if sc.StatsAddr() != tc.statsAddr {
+				t.Errorf("StatsAddr not equal: Expected: %s Actual: %s", tc.statsAddr, sc.StatsAddr())
+			}
+		})
+	}
+}
+
+func TestSetStatsAddr(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	if sc.StatsAddr() != "127.0.0.1:5000" {
+		t.Fatalf("StatsAddr not equal: Expected: 127.0.0.1:5000 Actual: %s", sc.StatsAddr())
+	}
+
+	sc.SetStatsAddr("127.0.0.1:6000")
+	if sc.StatsAddr() != "127.0.0.1:6000" {
+		t.Fatalf("StatsAddr not equal: Expected: 127.0.0.1:6000 Actual: %s", sc.StatsAddr())
+	}
+
+	expectedNullConfig := `
+[OUTPUT]
+    Name null
+    Match *
+    StatsAddr 127.0.0.1:6000
+`
+	if sc.String() != expectedNullConfig {
+		t.Errorf("Empty Config not equal: Expected: %s Actual: %s", expectedNullConfig, sc.String())
+	}
+
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{
+			{
+				Name:      "some-name",
+				Addr:      "example.com:12345",
+				Namespace: "some-namespace",
+			},
+		},
+		[]clusterSink{},
+	)
+	expectedConfig.Sections[1].KeyValues[2].Value = "127.0.0.1:6000"
+	if !cmp.Equal(f, expectedConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+func TestClusterSinkIncludeNamespaces(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-syslog-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+			IncludeNamespaces: []string{"ns-a", "ns-b"},
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-webhook-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "http://example.com/some/path",
+			},
+			IncludeNamespaces: []string{"ns-a", "ns-b"},
+		},
+	})
+
+	f, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := sinksToConfigAST(
+		t,
+		[]namespaceSink{},
+		[]clusterSink{
+			{
+				Addr:       "example.com:12345",
+				Name:       "some-syslog-name",
+				Namespaces: []string{"ns-a", "ns-b"},
+			},
+		},
+		httpOutputSection("*_ns-a_*", "example.com", "80", "/some/path"),
+		httpOutputSection("*_ns-b_*", "example.com", "80", "/some/path"),
+	)
+	if !cmp.Equal(f, expectedConfig, compareFLBConfig) {
+		t.Fatal(cmp.Diff(f, expectedConfig))
+	}
+}
+
+type clusterSink struct {
+	Addr              string     `json:"addr,omitempty"`
+	TLS               *tlsConfig `json:"tls,omitempty"`
+	Name              string     `json:"name,omitempty"`
+	Namespaces        []string   `json:"namespaces,omitempty"`
+	Matches           []string   `json:"matches,omitempty"`
+	ExcludeNamespaces []string   `json:"exclude_namespaces,omitempty"`
+	OnBackpressure    string     `json:"on_backpressure,omitempty"`
+	BackoffMs         int        `json:"reconnect_backoff_ms,omitempty"`
+	MaxMs             int        `json:"reconnect_max_ms,omitempty"`
+}
+
+type namespaceSink struct {
+	Addr           string     `json:"addr,omitempty"`
+	Namespace      string     `json:"namespace,omitempty"`
+	TLS            *tlsConfig `json:"tls,omitempty"`
+	Name           string     `json:"name,omitempty"`
+	RetryLimit     int        `json:"retry_limit,omitempty"`
+	Matches        []string   `json:"matches,omitempty"`
+	OnBackpressure string     `json:"on_backpressure,omitempty"`
+	BackoffMs      int        `json:"reconnect_backoff_ms,omitempty"`
+	MaxMs          int        `json:"reconnect_max_ms,omitempty"`
+}
+
+type tlsConfig struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+	CA                 string `json:"ca,omitempty"`
+}
+
+var compareFLBConfig = cmp.Comparer(func(x, y flbconfig.File) bool {
+	if x.Name != y.Name {
+		fmt.Println("file names do not match")
+		return false
+	}
+	if len(x.Sections) != len(y.Sections) {
+		fmt.Printf(
+			"section lengths differ: %d != %d\n",
+			len(x.Sections),
+			len(y.Sections),
+		)
+		return false
+	}
+
+	ySections := make([]flbconfig.Section, len(y.Sections))
+	copy(ySections, y.Sections)
 
 outer:
 	for _, xs := range x.Sections {
@@ -1272,6 +7668,23 @@ outer:
 	return true
 })
 
+func isolateFilterSection(match, tag, emitter string) flbconfig.Section {
+	directive := "Match"
+	if strings.HasPrefix(match, "^") {
+		directive = "Match_Regex"
+	}
+	return flbconfig.Section{
+		Name: "FILTER",
+		KeyValues: []flbconfig.KeyValue{
+			{Key: "Name", Value: "rewrite_tag"},
+			{Key: directive, Value: match},
+			{Key: "Rule", Value: "$log .* " + tag + " true"},
+			{Key: "Rule", Value: "$kubernetes['namespace_name'] .* " + tag + " true"},
+			{Key: "Emitter_Name", Value: emitter},
+		},
+	}
+}
+
 func sinksToConfigAST(
 	t *testing.T,
 	nsSinks []namespaceSink,
@@ -1323,3 +7736,63 @@ func sinksToConfigAST(
 		Sections: sections,
 	}
 }
+
+// withSyslogMatch returns f with the Match of its syslog output replaced,
+// e.g. with sink.* for the syslog output of isolated sinks.
+func withSyslogMatch(f flbconfig.File, match string) flbconfig.File {
+	for _, s := range f.Sections {
+		if len(s.KeyValues) > 1 && s.KeyValues[0].Value == "syslog" {
+			s.KeyValues[1].Value = match
+		}
+	}
+	return f
+}
+
+// systemNamespacesExcluded is the Match_Regex of the outputs of cluster sinks
+// matching every namespace but sink.SystemNamespaces.
+const systemNamespacesExcluded = `^(?!(?:.*_kube-system_.*|.*_kube-public_.*|.*_kube-node-lease_.*)$).*$`
+
+func matchKey(match string) string {
+	if strings.HasPrefix(match, "^") {
+		return "Match_Regex"
+	}
+	return "Match"
+}
+
+func httpOutputSection(
+	match string,
+	host string,
+	port string,
+	uri string,
+	extras ...flbconfig.KeyValue,
+) flbconfig.Section {
+	return flbconfig.Section{
+		Name: "OUTPUT",
+		KeyValues: append([]flbconfig.KeyValue{
+			{Key: "Name", Value: "http"},
+			{Key: matchKey(match), Value: match},
+			{Key: "Format", Value: "json"},
+			{Key: "Host", Value: host},
+			{Key: "Port", Value: port},
+			{Key: "URI", Value: uri},
+		}, extras...),
+	}
+}
+
+func gelfOutputSection(
+	match string,
+	mode string,
+	extras ...flbconfig.KeyValue,
+) flbconfig.Section {
+	return flbconfig.Section{
+		Name: "OUTPUT",
+		KeyValues: append([]flbconfig.KeyValue{
+			{Key: "Name", Value: "gelf"},
+			{Key: matchKey(match), Value: match},
+			{Key: "Host", Value: "graylog.example.com"},
+			{Key: "Port", Value: "12201"},
+			{Key: "Mode", Value: mode},
+			{Key: "Gelf_Short_Message_Key", Value: "log"},
+		}, extras...),
+	}
+}
@@ -18,7 +18,6 @@ package sink
 import (
 	"encoding/json"
 	"log"
-	"reflect"
 
 	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -107,7 +106,7 @@ func (c *Controller) OnUpdate(old, new interface{}) {
 	if !ok {
 		return
 	}
-	if !reflect.DeepEqual(o.Spec, n.Spec) {
+	if !v1alpha1.SinkSpecEqual(o.Spec, n.Spec) {
 		c.OnAdd(new)
 	}
 }
@@ -33,6 +33,7 @@ import (
 )
 
 func TestSinkModification(t *testing.T) {
+	insecure := true
 	var tests = []struct {
 		name       string
 		operations []string
@@ -64,7 +65,7 @@ func TestSinkModification(t *testing.T) {
 			"Add a single TLS sink with skip verify set",
 			[]string{"add"},
 			[]v1alpha1.SinkSpec{
-				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 12345, EnableTLS: true, InsecureSkipVerify: true}},
+				{Type: "syslog", SyslogSpec: v1alpha1.SyslogSpec{Host: "example.com", Port: 12345, EnableTLS: true, InsecureSkipVerify: &insecure}},
 			},
 			[]string{
 				"\n[OUTPUT]\n    Name syslog\n    Match *\n    StatsAddr 127.0.0.1:5000\n    Sinks [{\"addr\":\"example.com:12345\",\"namespace\":\"test-ns\",\"tls\":{\"insecure_skip_verify\":true},\"name\":\"sink-example.com\"}]\n    ClusterSinks []\n",
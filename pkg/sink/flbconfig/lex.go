@@ -49,6 +49,9 @@ const (
 	RuneLeftBracket  = '['
 	RuneRightBracket = ']'
 	RuneNewLine      = '\n'
+	RuneUnderscore   = '_'
+	RuneDot          = '.'
+	RuneHash         = '#'
 )
 
 type StateFunc func(*Lexer) StateFunc
@@ -118,11 +121,22 @@ func LexStart(l *Lexer) StateFunc {
 		return LexNewLine
 	case RuneLeftBracket:
 		return LexLeftBracket
+	case RuneHash:
+		return LexComment
 	default:
 		return LexKey
 	}
 }
 
+// LexComment skips a comment up to the end of the line.
+func LexComment(l *Lexer) StateFunc {
+	for !l.EOF() && l.PeekNext() != RuneNewLine {
+		l.Next()
+	}
+	l.Start = l.Pos
+	return LexStart
+}
+
 func LexEOF(l *Lexer) StateFunc {
 	l.Emit(TokenEOF)
 	return nil
@@ -194,7 +208,7 @@ func LexKey(l *Lexer) StateFunc {
 		}
 
 		next := l.PeekNext()
-		if !unicode.IsLetter(next) && !unicode.IsNumber(next) {
+		if !isKeyRune(next) {
 			switch next {
 			case RuneTab, RuneSpace:
 				l.Emit(TokenKey)
@@ -208,6 +222,15 @@ func LexKey(l *Lexer) StateFunc {
 	}
 }
 
+// isKeyRune reports whether r may appear in a key. Fluent Bit keys such as
+// Retry_Limit and tls.verify contain underscores and dots.
+func isKeyRune(r rune) bool {
+	return unicode.IsLetter(r) ||
+		unicode.IsNumber(r) ||
+		r == RuneUnderscore ||
+		r == RuneDot
+}
+
 func LexValue(l *Lexer) StateFunc {
 	for {
 		if l.EOF() {
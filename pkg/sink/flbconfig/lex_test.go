@@ -79,6 +79,92 @@ key [{"some": "json"}]
 				},
 			},
 		},
+		"comment": {
+			input: `# some comment
+[section]
+`,
+			expectedTokens: []flbconfig.Token{
+				{
+					Type:  flbconfig.TokenNewLine,
+					Value: "\n",
+				},
+				{
+					Type:  flbconfig.TokenLeftBracket,
+					Value: "[",
+				},
+				{
+					Type:  flbconfig.TokenSection,
+					Value: "section",
+				},
+				{
+					Type:  flbconfig.TokenRightBracket,
+					Value: "]",
+				},
+				{
+					Type:  flbconfig.TokenNewLine,
+					Value: "\n",
+				},
+				{
+					Type: flbconfig.TokenEOF,
+				},
+			},
+		},
+		"punctuated key": {
+			input: `
+[section]
+tls.verify Off
+Retry_Limit 5
+`,
+			expectedTokens: []flbconfig.Token{
+				{
+					Type:  flbconfig.TokenNewLine,
+					Value: "\n",
+				},
+				{
+					Type:  flbconfig.TokenLeftBracket,
+					Value: "[",
+				},
+				{
+					Type:  flbconfig.TokenSection,
+					Value: "section",
+				},
+				{
+					Type:  flbconfig.TokenRightBracket,
+					Value: "]",
+				},
+				{
+					Type:  flbconfig.TokenNewLine,
+					Value: "\n",
+				},
+				{
+					Type:  flbconfig.TokenKey,
+					Value: "tls.verify",
+				},
+				{
+					Type:  flbconfig.TokenValue,
+					Value: "Off",
+				},
+				{
+					Type:  flbconfig.TokenNewLine,
+					Value: "\n",
+				},
+				{
+					Type:  flbconfig.TokenKey,
+					Value: "Retry_Limit",
+				},
+				{
+					Type:  flbconfig.TokenValue,
+					Value: "5",
+				},
+				{
+					Type:  flbconfig.TokenNewLine,
+					Value: "\n",
+				},
+				{
+					Type: flbconfig.TokenEOF,
+				},
+			},
+		},
 		"extra whitespace": {
 			input: `
 				[section]
@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sink
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/knative/observability/pkg/sink/flbconfig"
+)
+
+// Format selects the syntax of the config rendered by String and
+// RenderChecked.
+type Format int
+
+const (
+	// ClassicFormat renders the classic Fluent Bit config of [OUTPUT] and
+	// [FILTER] sections.
+	ClassicFormat Format = iota
+	// YAMLFormat renders the structured YAML config accepted by newer
+	// Fluent Bit versions. Sections are listed under pipeline by kind, e.g.
+	// filters and outputs, with their keys in lower case. Comments added
+	// with WithSinkComments are not rendered.
+	YAMLFormat
+)
+
+// WithFormat sets the syntax of the rendered config. Defaults to
+// ClassicFormat. StringByType always renders the classic format.
+func WithFormat(f Format) ConfigOption {
+	return func(c *Config) {
+		c.format = f
+	}
+}
+
+// yamlKinds are the pipeline kinds of the sections of the classic config in
+// the order Fluent Bit documents them.
+var yamlKinds = []string{"inputs", "filters", "outputs"}
+
+func (sc *Config) applyFormat(config string) (string, error) {
+	if sc.format != YAMLFormat {
+		return config, nil
+	}
+	return toYAML(config)
+}
+
+// toYAML converts a classic config to the YAML config. The order of the
+// sections of each kind and of the keys of each section is kept so the
+// result is as deterministic as the classic config. A key repeated within a
+// section, such as Header, is rendered once with a list of its values. Every
+// value is double quoted to avoid YAML interpreting it.
+func toYAML(config string) (string, error) {
+	f, err := flbconfig.Parse("", config)
+	if err != nil {
+		return "", err
+	}
+
+	byKind := make(map[string][]flbconfig.Section)
+	kinds := append([]string{}, yamlKinds...)
+	for _, s := range f.Sections {
+		if s.Name == "" || len(s.KeyValues) == 0 {
+			continue
+		}
+		kind := strings.ToLower(s.Name) + "s"
+		if !contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+		byKind[kind] = append(byKind[kind], s)
+	}
+
+	var b strings.Builder
+	b.WriteString("pipeline:\n")
+	for _, kind := range kinds {
+		sections := byKind[kind]
+		if len(sections) == 0 {
+			continue
+		}
+		b.WriteString("  " + kind + ":\n")
+		for _, s := range sections {
+			var keys []string
+			values := make(map[string][]string)
+			for _, kv := range s.KeyValues {
+				k := strings.ToLower(kv.Key)
+				if _, ok := values[k]; !ok {
+					keys = append(keys, k)
+				}
+				values[k] = append(values[k], strconv.Quote(kv.Value))
+			}
+			for i, k := range keys {
+				prefix := "      "
+				if i == 0 {
+					prefix = "    - "
+				}
+				v := values[k][0]
+				if len(values[k]) > 1 {
+					v = "[" + strings.Join(values[k], ", ") + "]"
+				}
+				b.WriteString(prefix + k + ": " + v + "\n")
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sink
+
+import (
+	"bytes"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+)
+
+// manifest is a sink without its status and the metadata set by the API
+// server, so it can be applied to recreate the sink.
+type manifest struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        metav1.ObjectMeta `json:"metadata"`
+	Spec            v1alpha1.SinkSpec `json:"spec"`
+}
+
+// ExportManifests returns every tracked LogSink, ordered by namespace and
+// name, followed by every tracked ClusterLogSink, ordered by name, as a
+// multi-document YAML stream that can be applied with kubectl. Only the
+// name, namespace, labels and annotations of the metadata are kept. Sinks
+// rendered from templates set with WithSinkTemplates are left out.
+func (sc *Config) ExportManifests() ([]byte, error) {
+	var manifests []manifest
+	sc.ForEachSink(func(s *v1alpha1.LogSink) bool {
+		manifests = append(manifests, manifest{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+				Kind:       "LogSink",
+			},
+			Metadata: metav1.ObjectMeta{
+				Name:        s.Name,
+				Namespace:   s.Namespace,
+				Labels:      s.Labels,
+				Annotations: s.Annotations,
+			},
+			Spec: s.Spec,
+		})
+		return true
+	})
+	sc.ForEachClusterSink(func(s *v1alpha1.ClusterLogSink) bool {
+		manifests = append(manifests, manifest{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+				Kind:       "ClusterLogSink",
+			},
+			Metadata: metav1.ObjectMeta{
+				Name:        s.Name,
+				Labels:      s.Labels,
+				Annotations: s.Annotations,
+			},
+			Spec: s.Spec,
+		})
+		return true
+	})
+
+	var buf bytes.Buffer
+	for i, m := range manifests {
+		doc, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("unable to export %s %s: %s", m.Kind, m.Metadata.Name, err)
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(doc)
+	}
+	return buf.Bytes(), nil
+}
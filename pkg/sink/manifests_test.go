@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sink_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+	"github.com/knative/observability/pkg/sink"
+)
+
+func TestExportManifests(t *testing.T) {
+	sinks := []*v1alpha1.LogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "some-name",
+				Namespace:       "some-namespace",
+				Labels:          map[string]string{"app": "some-app"},
+				ResourceVersion: "123",
+				UID:             "some-uid",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:      "example.com",
+					Port:      12345,
+					EnableTLS: true,
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other-name",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/some/path",
+				},
+				Redact: []string{"password"},
+			},
+		},
+	}
+	clusterSinks := []*v1alpha1.ClusterLogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "some-cluster-name",
+				Annotations: map[string]string{"some-annotation": "some-value"},
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "example.com",
+					Port: 12201,
+				},
+				IncludeNamespaces: []string{"ns1", "ns2"},
+			},
+		},
+	}
+	sc := sink.NewConfig("127.0.0.1:5000")
+	for _, s := range sinks {
+		sc.UpsertSink(s)
+	}
+	for _, cs := range clusterSinks {
+		sc.UpsertClusterSink(cs)
+	}
+
+	exported, err := sc.ExportManifests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := strings.Split(string(exported), "---\n")
+	if len(docs) != 3 {
+		t.Fatalf("expected 3 documents, got:\n%s", exported)
+	}
+
+	sinks[0].ResourceVersion = ""
+	sinks[0].UID = ""
+	for i, expected := range []*v1alpha1.LogSink{sinks[1], sinks[0]} {
+		s := &v1alpha1.LogSink{}
+		if err := yaml.UnmarshalStrict([]byte(docs[i]), s); err != nil {
+			t.Fatal(err)
+		}
+		if s.APIVersion != "observability.knative.dev/v1alpha1" || s.Kind != "LogSink" {
+			t.Errorf("expected a LogSink, got %s %s", s.APIVersion, s.Kind)
+		}
+		if !cmp.Equal(s.ObjectMeta, expected.ObjectMeta) {
+			t.Error(cmp.Diff(s.ObjectMeta, expected.ObjectMeta))
+		}
+		if !cmp.Equal(s.Spec, expected.Spec) {
+			t.Error(cmp.Diff(s.Spec, expected.Spec))
+		}
+	}
+
+	cs := &v1alpha1.ClusterLogSink{}
+	if err := yaml.UnmarshalStrict([]byte(docs[2]), cs); err != nil {
+		t.Fatal(err)
+	}
+	if cs.APIVersion != "observability.knative.dev/v1alpha1" || cs.Kind != "ClusterLogSink" {
+		t.Errorf("expected a ClusterLogSink, got %s %s", cs.APIVersion, cs.Kind)
+	}
+	if !cmp.Equal(cs.ObjectMeta, clusterSinks[0].ObjectMeta) {
+		t.Error(cmp.Diff(cs.ObjectMeta, clusterSinks[0].ObjectMeta))
+	}
+	if !cmp.Equal(cs.Spec, clusterSinks[0].Spec) {
+		t.Error(cmp.Diff(cs.Spec, clusterSinks[0].Spec))
+	}
+
+	empty, err := sink.NewConfig("127.0.0.1:5000").ExportManifests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("expected nothing to be exported without sinks, got:\n%s", empty)
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+	"github.com/knative/observability/pkg/sink/flbconfig"
+)
+
+// ParseConfig reconstructs the sinks and cluster sinks from a config
+// rendered by Config with the DefaultMatchTemplate. Only the syslog and http
+// outputs rendered by this package are recognized, other sections are
+// ignored.
+//
+// The http output does not record the name of the sink it was rendered for
+// so webhook sinks are named webhook-0, webhook-1, etc. in the order they
+// appear. Cluster webhook sinks limited with IncludeNamespaces are parsed
+// as one LogSink per namespace. Filters are not recognized, so sinks whose
+// records are isolated are parsed without the settings filtering them.
+func ParseConfig(text string) ([]*v1alpha1.LogSink, []*v1alpha1.ClusterLogSink, error) {
+	f, err := flbconfig.Parse("", text)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		sinks        []*v1alpha1.LogSink
+		clusterSinks []*v1alpha1.ClusterLogSink
+		webhooks     int
+	)
+	for _, section := range f.Sections {
+		if !strings.EqualFold(section.Name, "OUTPUT") {
+			continue
+		}
+		kvs := make(map[string]string, len(section.KeyValues))
+		for _, kv := range section.KeyValues {
+			kvs[strings.ToLower(kv.Key)] = kv.Value
+		}
+
+		switch kvs["name"] {
+		case "syslog":
+			s, cs, err := parseSyslogOutput(kvs)
+			if err != nil {
+				return nil, nil, err
+			}
+			sinks = append(sinks, s...)
+			clusterSinks = append(clusterSinks, cs...)
+		case "http":
+			name := fmt.Sprintf("webhook-%d", webhooks)
+			webhooks++
+
+			s, cs, err := parseHTTPOutput(name, kvs)
+			if err != nil {
+				return nil, nil, err
+			}
+			if s != nil {
+				sinks = append(sinks, s)
+			}
+			if cs != nil {
+				clusterSinks = append(clusterSinks, cs)
+			}
+		}
+	}
+
+	return sinks, clusterSinks, nil
+}
+
+func parseSyslogOutput(kvs map[string]string) ([]*v1alpha1.LogSink, []*v1alpha1.ClusterLogSink, error) {
+	var nsSinks, clSinks []sink
+	if err := json.Unmarshal([]byte(kvs["sinks"]), &nsSinks); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse syslog sinks: %s", err)
+	}
+	if err := json.Unmarshal([]byte(kvs["clustersinks"]), &clSinks); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse syslog cluster sinks: %s", err)
+	}
+
+	sinks := make([]*v1alpha1.LogSink, 0, len(nsSinks))
+	for _, s := range nsSinks {
+		spec, err := parseSyslogSink(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		sinks = append(sinks, &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.Name,
+				Namespace: s.Namespace,
+			},
+			Spec: spec,
+		})
+	}
+
+	clusterSinks := make([]*v1alpha1.ClusterLogSink, 0, len(clSinks))
+	for _, s := range clSinks {
+		spec, err := parseSyslogSink(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		spec.IncludeNamespaces = s.Namespaces
+		spec.IncludeSystemNamespaces = len(s.Namespaces) == 0 && len(s.ExcludeNamespaces) == 0 && len(s.Matches) == 0
+		clusterSinks = append(clusterSinks, &v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: s.Name,
+			},
+			Spec: spec,
+		})
+	}
+
+	return sinks, clusterSinks, nil
+}
+
+func parseSyslogSink(s sink) (v1alpha1.SinkSpec, error) {
+	host, portStr, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return v1alpha1.SinkSpec{}, fmt.Errorf("syslog sink %s: %s", s.Name, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return v1alpha1.SinkSpec{}, fmt.Errorf("syslog sink %s: invalid port %q", s.Name, portStr)
+	}
+
+	spec := v1alpha1.SinkSpec{
+		Type: "syslog",
+		SyslogSpec: v1alpha1.SyslogSpec{
+			Host:               host,
+			Port:               port,
+			ReconnectBackoffMs: s.BackoffMs,
+			ReconnectMaxMs:     s.MaxMs,
+			StructuredData:     s.StructuredData,
+			Transport:          s.Transport,
+			SyslogFormat:       s.Format,
+			MessageKey:         s.MessageKey,
+		},
+		Matches: s.Matches,
+	}
+	if len(s.Matches) == 1 {
+		if _, ok := parseIsolationTag(s.Matches[0]); ok {
+			spec.Matches = nil
+		}
+	}
+	if s.TLS != nil {
+		spec.EnableTLS = true
+		if s.TLS.InsecureSkipVerify {
+			v := true
+			spec.InsecureSkipVerify = &v
+		}
+		spec.CAFile = s.TLS.CAFile
+		spec.CertFile = s.TLS.CertFile
+		spec.KeyFile = s.TLS.KeyFile
+	}
+	return spec, nil
+}
+
+func parseHTTPOutput(name string, kvs map[string]string) (*v1alpha1.LogSink, *v1alpha1.ClusterLogSink, error) {
+	scheme := "http"
+	if strings.EqualFold(kvs["tls"], "On") || strings.EqualFold(kvs["tls.on"], "On") {
+		scheme = "https"
+	}
+	host := kvs["host"]
+	if port := kvs["port"]; port != "" && !isDefaultPort(scheme, port) {
+		host = net.JoinHostPort(host, port)
+	}
+
+	spec := v1alpha1.SinkSpec{
+		Type: "webhook",
+		WebhookSpec: v1alpha1.WebhookSpec{
+			URL: fmt.Sprintf("%s://%s%s", scheme, host, kvs["uri"]),
+		},
+		TimeKey:    kvs["json_date_key"],
+		DateFormat: kvs["json_date_format"],
+	}
+	spec.CA = kvs["tls.ca_file"]
+	spec.ClientCert = kvs["tls.crt_file"]
+	spec.ClientKey = kvs["tls.key_file"]
+	spec.TLSVHost = kvs["tls.vhost"]
+	if verify, ok := kvs["tls.verify"]; ok {
+		v := strings.EqualFold(verify, "On")
+		spec.TLSVerify = &v
+	}
+	if workers, ok := kvs["workers"]; ok {
+		n, err := strconv.Atoi(workers)
+		if err != nil {
+			return nil, nil, fmt.Errorf("http output %s: invalid workers %q", name, workers)
+		}
+		spec.Workers = n
+	}
+	if keepAlive, ok := kvs["net.keepalive"]; ok {
+		v := strings.EqualFold(keepAlive, "On")
+		spec.KeepAlive = &v
+	}
+	if timeout, ok := kvs["net.connect_timeout"]; ok {
+		n, err := strconv.Atoi(timeout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("http output %s: invalid connect timeout %q", name, timeout)
+		}
+		spec.ConnectTimeoutSeconds = n
+	}
+	spec.HTTP2 = strings.EqualFold(kvs["http2"], "On")
+
+	match := kvs["match"]
+	namespace, isolated := parseIsolationTag(match)
+	if isolated && namespace != "" {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: spec,
+		}, nil, nil
+	}
+	if isolated || match == "*" || kvs["match_regex"] != "" {
+		spec.IncludeSystemNamespaces = match == "*" || kvs["match_regex"] == excludeIsolationTags("*")
+		return nil, &v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: spec,
+		}, nil
+	}
+	if !strings.HasPrefix(match, "*_") || !strings.HasSuffix(match, "_*") || len(match) < 4 {
+		return nil, nil, fmt.Errorf("http output %s: unrecognized match %q", name, match)
+	}
+
+	return &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: match[2 : len(match)-2],
+		},
+		Spec: spec,
+	}, nil, nil
+}
+
+// parseIsolationTag returns the namespace of the sink whose records are
+// copied to tag, empty for a cluster sink, and whether tag is an
+// isolationTag.
+func parseIsolationTag(tag string) (string, bool) {
+	rest := strings.TrimPrefix(tag, isolationTagPrefix)
+	switch {
+	case rest == tag:
+		return "", false
+	case strings.HasPrefix(rest, "cluster."):
+		return "", true
+	case strings.HasPrefix(rest, "ns."):
+		parts := strings.SplitN(strings.TrimPrefix(rest, "ns."), ".", 2)
+		return parts[0], len(parts) == 2
+	}
+	return "", false
+}
+
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "https" && port == "443") || (scheme == "http" && port == "80")
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sink_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+	"github.com/knative/observability/pkg/sink"
+	"github.com/knative/observability/pkg/sink/flbconfig"
+)
+
+func TestParseConfig(t *testing.T) {
+	insecure := true
+	sinks := []*v1alpha1.LogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-syslog-name",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host:               "example.com",
+					Port:               12345,
+					EnableTLS:          true,
+					InsecureSkipVerify: &insecure,
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "webhook-0",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com:8443/some/path",
+				},
+				TimeKey: "@timestamp",
+				Workers: 2,
+			},
+		},
+	}
+	clusterSinks := []*v1alpha1.ClusterLogSink{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "some-cluster-syslog-name",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "syslog",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: "cluster.example.com",
+					Port: 12346,
+				},
+				IncludeNamespaces: []string{"ns-a", "ns-b"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "webhook-1",
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "http://cluster.example.com/some/path",
+				},
+			},
+		},
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.ReplaceAll(sinks, clusterSinks)
+	rendered := sc.String()
+
+	parsedSinks, parsedClusterSinks, err := sink.ParseConfig(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsedSinks) != len(sinks) {
+		t.Fatalf("expected %d sinks, got %d", len(sinks), len(parsedSinks))
+	}
+	if len(parsedClusterSinks) != len(clusterSinks) {
+		t.Fatalf("expected %d cluster sinks, got %d", len(clusterSinks), len(parsedClusterSinks))
+	}
+
+	// Syslog sinks are rendered before webhook sinks so they are parsed
+	// first.
+	if !cmp.Equal(parsedSinks[0].Spec, sinks[0].Spec) {
+		t.Error(cmp.Diff(parsedSinks[0].Spec, sinks[0].Spec))
+	}
+	if parsedSinks[0].Namespace != "some-namespace" || parsedSinks[0].Name != "some-syslog-name" {
+		t.Errorf("unexpected sink %s/%s", parsedSinks[0].Namespace, parsedSinks[0].Name)
+	}
+	if !cmp.Equal(parsedClusterSinks[0].Spec, clusterSinks[0].Spec) {
+		t.Error(cmp.Diff(parsedClusterSinks[0].Spec, clusterSinks[0].Spec))
+	}
+	if parsedClusterSinks[0].Name != "some-cluster-syslog-name" {
+		t.Errorf("unexpected cluster sink %s", parsedClusterSinks[0].Name)
+	}
+
+	roundTrip := sink.NewConfig("127.0.0.1:5000")
+	roundTrip.ReplaceAll(parsedSinks, parsedClusterSinks)
+
+	expected, err := flbconfig.Parse("", rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := flbconfig.Parse("", roundTrip.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(actual, expected, compareFLBConfig) {
+		t.Error(cmp.Diff(actual, expected))
+	}
+}
+
+func TestParseConfigIsolatedSinks(t *testing.T) {
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-syslog-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+			SampleRate: 10,
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "webhook-0",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "https://example.com/some/path",
+			},
+			SampleRate: 10,
+		},
+	})
+
+	sinks, clusterSinks, err := sink.ParseConfig(sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sinks) != 2 || len(clusterSinks) != 0 {
+		t.Fatalf("expected 2 sinks, got %d sinks and %d cluster sinks", len(sinks), len(clusterSinks))
+	}
+	for _, s := range sinks {
+		if s.Namespace != "some-namespace" || len(s.Spec.Matches) != 0 {
+			t.Errorf("expected sink %s in some-namespace without matches, got %s with %v", s.Name, s.Namespace, s.Spec.Matches)
+		}
+	}
+}
+
+func TestParseConfigEmpty(t *testing.T) {
+	sinks, clusterSinks, err := sink.ParseConfig(sink.NewConfig("127.0.0.1:5000").String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sinks) != 0 || len(clusterSinks) != 0 {
+		t.Errorf("expected no sinks, got %d sinks and %d cluster sinks", len(sinks), len(clusterSinks))
+	}
+}
+
+func TestParseConfigInvalidSyslogSinks(t *testing.T) {
+	_, _, err := sink.ParseConfig(`
+[OUTPUT]
+    Name syslog
+    Match *
+    StatsAddr 127.0.0.1:5000
+    Sinks [{"addr":"example.com"}]
+    ClusterSinks []
+`)
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sink
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OutputRenderer renders the outputs of a sink of the type it is registered
+// for with WithOutputRenderer. Cluster sinks are given as a LogSink without a
+// namespace.
+type OutputRenderer interface {
+	Render(sink *v1alpha1.LogSink) (string, error)
+}
+
+// OutputRendererFunc adapts a function to an OutputRenderer.
+type OutputRendererFunc func(sink *v1alpha1.LogSink) (string, error)
+
+// Render calls f(sink).
+func (f OutputRendererFunc) Render(sink *v1alpha1.LogSink) (string, error) {
+	return f(sink)
+}
+
+// reservedTypes are rendered by Config itself and cannot be given a
+// renderer. Every syslog sink shares a single output.
+var reservedTypes = map[string]bool{
+	"null":       true,
+	"syslog":     true,
+	"sample":     true,
+	"severity":   true,
+	"redact":     true,
+	"truncate":   true,
+	"parser":     true,
+	"source":     true,
+	"isolate":    true,
+	catchAllType: true,
+	healthType:   true,
+}
+
+// catchAllType is the key of the outputs of catch-all cluster sinks in the
+// map returned by StringByType. They are rendered after every other type
+// but the health output.
+const catchAllType = "catchall"
+
+// healthType is the key of the output set with WithHealthOutput in the map
+// returned by StringByType. It is rendered last.
+const healthType = "health"
+
+// WithOutputRenderer registers the renderer for sinks of the given type,
+// replacing the built in renderer if there is one. Registering a reserved
+// type such as syslog is logged and ignored.
+func WithOutputRenderer(sinkType string, r OutputRenderer) ConfigOption {
+	return func(c *Config) {
+		if reservedTypes[sinkType] {
+			log.Printf("unable to register renderer for reserved type %q", sinkType)
+			return
+		}
+		c.renderers[sinkType] = r
+	}
+}
+
+// MatchPatterns returns the Match patterns selecting the records routed to
+// the sink. A sink without a namespace is treated as a cluster sink. Cluster
+// sinks leaving out SystemNamespaces are given a regex, so patterns should
+// be rendered with MatchDirective. The records of sinks filtering them are
+// copied to a tag of their own, which is the only pattern returned for them.
+func (sc *Config) MatchPatterns(s *v1alpha1.LogSink) []string {
+	if isolated(s.Spec) {
+		return []string{isolationTag(s.Namespace, s.Name)}
+	}
+	return sc.sourceMatches(s)
+}
+
+func (sc *Config) builtinRenderers() map[string]OutputRenderer {
+	perMatch := func(build func(match string, s *v1alpha1.LogSink) (string, error)) OutputRenderer {
+		return OutputRendererFunc(func(s *v1alpha1.LogSink) (string, error) {
+			var config string
+			for _, match := range sc.MatchPatterns(s) {
+				c, err := build(match, s)
+				if err != nil {
+					return "", err
+				}
+				config += c
+			}
+			return config, nil
+		})
+	}
+
+	return map[string]OutputRenderer{
+		"webhook": OutputRendererFunc(func(s *v1alpha1.LogSink) (string, error) {
+			if s.Namespace == "" && s.Spec.RouteByField != "" {
+				return sc.buildRoutedHTTPConfig(s, sc.retryLimits[key(s)])
+			}
+			if s.Namespace == "" && len(s.Spec.Routes) > 0 {
+				return sc.buildRoutesHTTPConfig(s, sc.retryLimits[key(s)])
+			}
+			return perMatch(func(match string, s *v1alpha1.LogSink) (string, error) {
+				return sc.buildHTTPConfig(match, s.Spec, sc.retryLimits[key(s)])
+			}).Render(s)
+		}),
+		"gelf": perMatch(func(match string, s *v1alpha1.LogSink) (string, error) {
+			return buildGELFConfig(match, s.Spec), nil
+		}),
+		"otlp": perMatch(func(match string, s *v1alpha1.LogSink) (string, error) {
+			return buildOTLPConfig(match, s.Spec), nil
+		}),
+		"datadog": perMatch(func(match string, s *v1alpha1.LogSink) (string, error) {
+			return buildDatadogConfig(match, s.Spec), nil
+		}),
+		"azure": perMatch(func(match string, s *v1alpha1.LogSink) (string, error) {
+			return buildAzureConfig(match, s.Spec), nil
+		}),
+	}
+}
+
+// outputTypes returns OutputTypes followed by the types of registered
+// renderers that are not built in, sorted, the catch-all type and the health
+// type.
+func (sc *Config) outputTypes() []string {
+	known := make(map[string]bool, len(OutputTypes))
+	for _, t := range OutputTypes {
+		known[t] = true
+	}
+
+	var custom []string
+	for t := range sc.renderers {
+		if !known[t] {
+			custom = append(custom, t)
+		}
+	}
+	sort.Strings(custom)
+
+	types := append(append([]string{}, OutputTypes...), custom...)
+	return append(types, catchAllType, healthType)
+}
+
+// rendererConfig renders every enabled sink of the given type that is not a
+// catch-all with the renderer registered for it, ordered by namespace and
+// name with cluster sinks last. Sinks that fail to render are left out and
+// the first failure is returned.
+func (sc *Config) rendererConfig(sinkType string) (string, error) {
+	var (
+		config   string
+		firstErr error
+	)
+	all := sc.allSinks()
+	keys := make([]string, 0, len(all))
+	for k, s := range all {
+		if s.Spec.Type == sinkType && !s.Spec.Disabled {
+			keys = append(keys, k)
+		}
+	}
+	sc.sortKeys(keys)
+	for _, k := range keys {
+		c, err := sc.renderSinkFragment(all[k])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		config += c
+	}
+
+	keys = keys[:0]
+	for k, s := range sc.clusterSinks {
+		if s.Spec.Type == sinkType && !s.Spec.Disabled && !s.Spec.CatchAll {
+			keys = append(keys, k)
+		}
+	}
+	c, err := sc.renderClusterSinks(keys)
+	if firstErr == nil {
+		firstErr = err
+	}
+
+	return config + c, firstErr
+}
+
+// catchAllConfig renders the outputs of every enabled catch-all cluster sink
+// with a registered renderer, ordered by name. Catch-all syslog sinks are
+// part of the shared syslog output instead.
+func (sc *Config) catchAllConfig() (string, error) {
+	var keys []string
+	for k, s := range sc.clusterSinks {
+		if s.Spec.CatchAll && !s.Spec.Disabled && sc.renderers[s.Spec.Type] != nil {
+			keys = append(keys, k)
+		}
+	}
+	return sc.renderClusterSinks(keys)
+}
+
+// renderClusterSinks renders the cluster sinks with the given keys, sorted
+// with sortClusterKeys, with the renderers of their types. Sinks that fail
+// to render are left out and the first failure is returned.
+func (sc *Config) renderClusterSinks(keys []string) (string, error) {
+	var (
+		config   string
+		firstErr error
+	)
+	sc.sortClusterKeys(keys)
+	for _, k := range keys {
+		c, err := sc.renderClusterSinkFragment(sc.clusterSinks[k])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		config += c
+	}
+	return config, firstErr
+}
+
+// renderSinkFragment renders the outputs of a sink with the renderer of its
+// type, along with its alias and comment.
+func (sc *Config) renderSinkFragment(s *v1alpha1.LogSink) (string, error) {
+	c, err := sc.renderers[s.Spec.Type].Render(s)
+	if err != nil {
+		return "", fmt.Errorf("sink %s/%s: %s", s.Namespace, s.Name, err)
+	}
+	return sc.withComments(sc.withAliases(c, sinkAlias(s.Namespace, s.Name)), sinkComment(s.Namespace, s.Name)), nil
+}
+
+// renderClusterSinkFragment renders the outputs of a cluster sink like
+// renderSinkFragment, as a LogSink without a namespace.
+func (sc *Config) renderClusterSinkFragment(s *v1alpha1.ClusterLogSink) (string, error) {
+	c, err := sc.renderers[s.Spec.Type].Render(clusterLogSink(s))
+	if err != nil {
+		return "", fmt.Errorf("cluster sink %s: %s", s.Name, err)
+	}
+	return sc.withComments(sc.withAliases(c, sinkAlias("", s.Name)), sinkComment("", s.Name)), nil
+}
+
+// clusterLogSink returns a cluster sink as a LogSink without a namespace.
+func clusterLogSink(s *v1alpha1.ClusterLogSink) *v1alpha1.LogSink {
+	return &v1alpha1.LogSink{
+		TypeMeta: s.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        s.Name,
+			Labels:      s.Labels,
+			Annotations: s.Annotations,
+		},
+		Spec: s.Spec,
+	}
+}
+
+// ClusterFragmentPrefix prefixes the names of cluster sinks in the keys of
+// RenderFragments. It is not a valid namespace name, so the keys of cluster
+// sinks cannot collide with those of sinks.
+const ClusterFragmentPrefix = "_cluster"
+
+// RenderFragments renders every enabled sink with a renderer separately,
+// keyed by namespace|name for sinks and ClusterFragmentPrefix|name for
+// cluster sinks, so they can be cached and only rendered again when the sink
+// changes. A fragment holds the outputs of the sink along with its tail
+// input and the filters rendered for it, in the order of OutputTypes, so the
+// sections of the fragments together are those of String when every sink
+// has a renderer. Syslog sinks share a single output and are left out, as
+// are sinks failing to render.
+func (sc *Config) RenderFragments() map[string]string {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	fragments := make(map[string]string)
+	for k, s := range sc.allSinks() {
+		if s.Spec.Disabled || sc.renderers[s.Spec.Type] == nil {
+			continue
+		}
+		if c, err := sc.renderSinkFragment(s); err == nil {
+			fragments[k] = sc.withFilters(s, c)
+		}
+	}
+	for _, s := range sc.clusterSinks {
+		if s.Spec.Disabled || sc.renderers[s.Spec.Type] == nil {
+			continue
+		}
+		if c, err := sc.renderClusterSinkFragment(s); err == nil {
+			fragments[ClusterFragmentPrefix+"|"+s.Name] = sc.withFilters(clusterLogSink(s), c)
+		}
+	}
+	return fragments
+}
+
+// withFilters returns the outputs of a sink preceded by its tail input, its
+// isolate filters and its parser filter, and followed by its other filters.
+func (sc *Config) withFilters(s *v1alpha1.LogSink, outputs string) string {
+	var config string
+	if s.Namespace == "" {
+		config += sourceInputConfig(s.Spec)
+	}
+	config += sc.isolateSinkConfig(s) + sc.sinkFilterConfig(s, parserFilter) + outputs
+	for _, filter := range []func(string, v1alpha1.SinkSpec) string{
+		sampleFilter, severityFilter, redactFilter, truncateFilter,
+	} {
+		config += sc.sinkFilterConfig(s, filter)
+	}
+	return config
+}
+
+// RenderSink renders the outputs and filters the LogSink contributes to the
+// config of a Config created with the same stats address and options. Every
+// syslog sink shares a single output, so a syslog sink renders that output
+// with only itself. A disabled sink renders nothing.
+func RenderSink(statsAddr string, s *v1alpha1.LogSink, opts ...ConfigOption) (string, error) {
+	sc := NewConfig(statsAddr, opts...)
+	sc.UpsertSink(s)
+	return sc.renderSingle()
+}
+
+// RenderClusterSink renders the outputs and filters the ClusterLogSink
+// contributes to the config, like RenderSink.
+func RenderClusterSink(statsAddr string, cs *v1alpha1.ClusterLogSink, opts ...ConfigOption) (string, error) {
+	sc := NewConfig(statsAddr, opts...)
+	sc.UpsertClusterSink(cs)
+	return sc.renderSingle()
+}
+
+func (sc *Config) renderSingle() (string, error) {
+	if sc.IsEmpty() {
+		return "", nil
+	}
+	return sc.RenderChecked()
+}
@@ -0,0 +1,454 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sink_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+	"github.com/knative/observability/pkg/sink"
+	"github.com/knative/observability/pkg/sink/flbconfig"
+)
+
+func TestOutputRenderer(t *testing.T) {
+	var sc *sink.Config
+	fake := sink.OutputRendererFunc(func(s *v1alpha1.LogSink) (string, error) {
+		if s.Name == "broken" {
+			return "", errors.New("some-error")
+		}
+		return fmt.Sprintf(
+			"\n[OUTPUT]\n    Name custom\n    %s\n    Id %s\n",
+			sink.MatchDirective(strings.Join(sc.MatchPatterns(s), ",")),
+			s.Name,
+		), nil
+	})
+	sc = sink.NewConfig("127.0.0.1:5000", sink.WithOutputRenderer("custom", fake))
+
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "custom",
+		},
+	})
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-cluster-name",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "custom",
+		},
+	})
+
+	config, err := sc.RenderChecked()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"\n[OUTPUT]\n    Name custom\n    Match *_some-namespace_*\n    Id some-name\n",
+		"\n[OUTPUT]\n    Name custom\n    Match_Regex " + systemNamespacesExcluded + "\n    Id some-cluster-name\n",
+	} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("expected config to contain %q, got:\n%s", expected, config)
+		}
+	}
+	if _, ok := sc.StringByType()["custom"]; !ok {
+		t.Error("expected the custom type to be rendered separately")
+	}
+
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "broken",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "custom",
+		},
+	})
+	_, err = sc.RenderChecked()
+	if err == nil || err.Error() != "sink some-namespace/broken: some-error" {
+		t.Errorf("expected the renderer error to name the sink, got: %v", err)
+	}
+}
+
+func TestOutputRendererReplacesBuiltin(t *testing.T) {
+	fake := sink.OutputRendererFunc(func(s *v1alpha1.LogSink) (string, error) {
+		return "\n[OUTPUT]\n    Name replaced\n", nil
+	})
+	sc := sink.NewConfig(
+		"127.0.0.1:5000",
+		sink.WithOutputRenderer("webhook", fake),
+		sink.WithOutputRenderer("syslog", fake),
+	)
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-name",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "https://example.com/some/path",
+			},
+		},
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "some-syslog",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+
+	config := sc.String()
+	if strings.Count(config, "Name replaced") != 1 {
+		t.Errorf("expected the webhook renderer to be replaced, got:\n%s", config)
+	}
+	if !strings.Contains(config, "Name syslog") {
+		t.Errorf("expected syslog to be rendered by the built in renderer, got:\n%s", config)
+	}
+}
+
+func TestRenderSink(t *testing.T) {
+	syslogSink := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "syslog-sink",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	}
+	webhookSink := &v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "webhook-sink",
+			Namespace: "ns2",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "https://example.com/some/path",
+			},
+			SampleRate: 10,
+		},
+	}
+	clusterSink := &v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster-sink",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "gelf",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12201,
+			},
+			IncludeNamespaces: []string{"ns1", "ns2"},
+		},
+	}
+	opts := []sink.ConfigOption{sink.WithKeyCase(sink.LowerCase)}
+
+	sc := sink.NewConfig("127.0.0.1:5000", opts...)
+	sc.UpsertSink(syslogSink)
+	sc.UpsertSink(webhookSink)
+	sc.UpsertClusterSink(clusterSink)
+	config := sc.String()
+
+	webhook, err := sink.RenderSink("127.0.0.1:5000", webhookSink, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster, err := sink.RenderClusterSink("127.0.0.1:5000", clusterSink, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rendered := range []string{webhook, cluster} {
+		for _, stanza := range strings.Split(rendered, "\n[")[1:] {
+			if !strings.Contains(config, "\n["+stanza) {
+				t.Errorf("expected config to contain %q, got:\n%s", stanza, config)
+			}
+		}
+	}
+	if strings.Count(webhook, "[OUTPUT]") != 1 || strings.Count(webhook, "[FILTER]") != 2 {
+		t.Errorf("expected the webhook output, its isolate filter and its sample filter, got:\n%s", webhook)
+	}
+	if strings.Count(cluster, "[OUTPUT]") != 2 {
+		t.Errorf("expected a gelf output per included namespace, got:\n%s", cluster)
+	}
+
+	syslog, err := sink.RenderSink("127.0.0.1:5000", syslogSink, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syslogOnly := sink.NewConfig("127.0.0.1:5000", opts...)
+	syslogOnly.UpsertSink(syslogSink)
+	if syslog != syslogOnly.String() {
+		t.Errorf("expected the syslog output with only the sink, got:\n%s", syslog)
+	}
+
+	webhookSink.Spec.Disabled = true
+	disabled, err := sink.RenderSink("127.0.0.1:5000", webhookSink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled != "" {
+		t.Errorf("expected a disabled sink to render nothing, got:\n%s", disabled)
+	}
+}
+
+func TestRenderFragments(t *testing.T) {
+	webhookSink := func(namespace, name string) *v1alpha1.LogSink {
+		return &v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "webhook",
+				WebhookSpec: v1alpha1.WebhookSpec{
+					URL: "https://example.com/" + name,
+				},
+			},
+		}
+	}
+	gelfClusterSink := func(name string, catchAll bool) *v1alpha1.ClusterLogSink {
+		return &v1alpha1.ClusterLogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.SinkSpec{
+				Type: "gelf",
+				SyslogSpec: v1alpha1.SyslogSpec{
+					Host: name + ".example.com",
+					Port: 12201,
+				},
+				IncludeNamespaces: []string{"ns1", "ns2"},
+				CatchAll:          catchAll,
+			},
+		}
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000", sink.WithSinkComments(true))
+	sc.UpsertSink(webhookSink("ns2", "webhook-b"))
+	sc.UpsertSink(webhookSink("ns1", "webhook-a"))
+	disabled := webhookSink("ns1", "webhook-disabled")
+	disabled.Spec.Disabled = true
+	sc.UpsertSink(disabled)
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "syslog-sink",
+			Namespace: "ns1",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "syslog",
+			SyslogSpec: v1alpha1.SyslogSpec{
+				Host: "example.com",
+				Port: 12345,
+			},
+		},
+	})
+	sc.UpsertClusterSink(gelfClusterSink("gelf-a", false))
+	sc.UpsertClusterSink(gelfClusterSink("gelf-catch-all", true))
+
+	fragments := sc.RenderFragments()
+	keys := make([]string, 0, len(fragments))
+	for k := range fragments {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	expectedKeys := []string{sink.ClusterFragmentPrefix + "|gelf-a", sink.ClusterFragmentPrefix + "|gelf-catch-all", "ns1|webhook-a", "ns2|webhook-b"}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Fatalf("fragment keys not equal: Expected: %v Actual: %v", expectedKeys, keys)
+	}
+	if !strings.Contains(fragments["ns1|webhook-a"], "URI /webhook-a\n") ||
+		strings.Contains(fragments["ns1|webhook-a"], "webhook-b") {
+		t.Errorf("expected only the output of webhook-a, got:\n%s", fragments["ns1|webhook-a"])
+	}
+	if strings.Count(fragments[sink.ClusterFragmentPrefix+"|gelf-a"], "[OUTPUT]") != 2 {
+		t.Errorf("expected a gelf output per included namespace, got:\n%s", fragments[sink.ClusterFragmentPrefix+"|gelf-a"])
+	}
+
+	byType := sc.StringByType()
+	expected := map[string]string{
+		"webhook":  fragments["ns1|webhook-a"] + fragments["ns2|webhook-b"],
+		"gelf":     fragments[sink.ClusterFragmentPrefix+"|gelf-a"],
+		"catchall": fragments[sink.ClusterFragmentPrefix+"|gelf-catch-all"],
+	}
+	for sinkType, config := range expected {
+		if byType[sinkType] != config {
+			t.Errorf("%s fragments not equal to StringByType: Expected: %s Actual: %s", sinkType, byType[sinkType], config)
+		}
+	}
+}
+
+func TestRenderFragmentsOfIsolatedSinks(t *testing.T) {
+	webhookSpec := func(name string) v1alpha1.SinkSpec {
+		return v1alpha1.SinkSpec{
+			Type: "webhook",
+			WebhookSpec: v1alpha1.WebhookSpec{
+				URL: "https://example.com/" + name,
+			},
+		}
+	}
+
+	sc := sink.NewConfig("127.0.0.1:5000")
+	sampled := webhookSpec("sampled")
+	sampled.SampleRate = 10
+	sampled.Redact = []string{"password"}
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{Name: "sampled", Namespace: "cluster"},
+		Spec:       sampled,
+	})
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit", Namespace: "cluster"},
+		Spec:       webhookSpec("plain"),
+	})
+	audit := webhookSpec("audit")
+	audit.MinSeverity = "warning"
+	audit.Parser = "json"
+	audit.MaxRecordBytes = 1024
+	audit.TailSource = &v1alpha1.SourceSpec{
+		Path: "/var/log/audit.log",
+		Tag:  "audit",
+	}
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit"},
+		Spec:       audit,
+	})
+	stripped := webhookSpec("stripped")
+	stripped.StripKubernetesMetadata = true
+	sc.UpsertClusterSink(&v1alpha1.ClusterLogSink{
+		ObjectMeta: metav1.ObjectMeta{Name: "stripped"},
+		Spec:       stripped,
+	})
+
+	fragments := sc.RenderFragments()
+	for _, k := range []string{"cluster|audit", sink.ClusterFragmentPrefix + "|audit"} {
+		if fragments[k] == "" {
+			t.Fatalf("expected a fragment keyed by %s, got: %v", k, fragments)
+		}
+	}
+	if fragments["cluster|audit"] == fragments[sink.ClusterFragmentPrefix+"|audit"] {
+		t.Fatal("expected the fragments of a sink and a cluster sink of the same name to differ")
+	}
+
+	keys := make([]string, 0, len(fragments))
+	for k := range fragments {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var joined string
+	for _, k := range keys {
+		joined += fragments[k]
+	}
+	actual, err := flbconfig.Parse("", joined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := flbconfig.Parse("", sc.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(actual, expected, compareFLBConfig) {
+		t.Errorf("joined fragments not equal to String: Expected: %s Actual: %s", sc.String(), joined)
+	}
+}
+
+func TestRenderWithTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	slow := sink.OutputRendererFunc(func(s *v1alpha1.LogSink) (string, error) {
+		if s.Name == "slow" {
+			<-block
+		}
+		return "\n[OUTPUT]\n    Name custom\n    Match *\n", nil
+	})
+	sc := sink.NewConfig("127.0.0.1:5000", sink.WithOutputRenderer("custom", slow))
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fast",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "custom",
+		},
+	})
+
+	config, err := sc.RenderWithTimeout(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := sc.RenderChecked(); config != expected {
+		t.Errorf("expected the config rendered by RenderChecked, got:\n%s", config)
+	}
+
+	sc.UpsertSink(&v1alpha1.LogSink{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "slow",
+			Namespace: "some-namespace",
+		},
+		Spec: v1alpha1.SinkSpec{
+			Type: "custom",
+		},
+	})
+	start := time.Now()
+	_, err = sc.RenderWithTimeout(50 * time.Millisecond)
+	if err != sink.ErrRenderTimeout {
+		t.Fatalf("expected ErrRenderTimeout, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the timeout to fire after 50ms, took %s", elapsed)
+	}
+
+	updated := make(chan struct{})
+	go func() {
+		sc.DeleteSink(&v1alpha1.LogSink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "slow",
+				Namespace: "some-namespace",
+			},
+		})
+		close(updated)
+	}()
+	select {
+	case <-updated:
+	case <-time.After(time.Second):
+		t.Fatal("expected the config not to be locked by the timed out render")
+	}
+	if _, err := sc.RenderWithTimeout(time.Second); err != nil {
+		t.Errorf("expected the config to render without the slow sink, got: %v", err)
+	}
+}
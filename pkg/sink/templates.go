@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"text/template"
+
+	"github.com/knative/observability/pkg/apis/sink/v1alpha1"
+)
+
+// WithSinkTemplates sets sinks that are rendered for every namespace added
+// with AddNamespace, without a LogSink being created in it. The name and
+// every string field of the spec of a template may refer to the namespace as
+// {{.Namespace}}, e.g. a host of "{{.Namespace}}.logs.example.com". The
+// outputs of a templated sink match the namespace like those of any other
+// sink. A LogSink with the same namespace and name as a templated sink takes
+// precedence over it. An invalid template is logged and ignored.
+func WithSinkTemplates(templates ...*v1alpha1.LogSink) ConfigOption {
+	return func(c *Config) {
+		c.sinkTemplates = nil
+		for _, s := range templates {
+			text, err := json.Marshal(s)
+			if err != nil {
+				log.Printf("unable to marshal sink template %s: %s", s.Name, err)
+				continue
+			}
+			t, err := template.New(s.Name).Parse(string(text))
+			if err != nil {
+				log.Printf("invalid sink template %s: %s", s.Name, err)
+				continue
+			}
+			c.sinkTemplates = append(c.sinkTemplates, t)
+		}
+	}
+}
+
+// AddNamespace adds a namespace the templates set with WithSinkTemplates are
+// rendered for.
+func (sc *Config) AddNamespace(namespace string) {
+	sc.mu.Lock()
+	changed := !sc.namespaces[namespace] && len(sc.sinkTemplates) > 0
+	sc.namespaces[namespace] = true
+	for _, t := range sc.sinkTemplates {
+		s, err := expandSinkTemplate(t, namespace)
+		if err != nil {
+			log.Printf("unable to expand sink template %s for namespace %s: %s", t.Name(), namespace, err)
+			continue
+		}
+		sc.templatedSinks[key(s)] = s
+	}
+	sc.mu.Unlock()
+	sc.notify(changed)
+}
+
+// DeleteNamespace removes the sinks rendered from templates for a namespace
+// added with AddNamespace.
+func (sc *Config) DeleteNamespace(namespace string) {
+	sc.mu.Lock()
+	changed := sc.namespaces[namespace] && len(sc.sinkTemplates) > 0
+	delete(sc.namespaces, namespace)
+	for k, s := range sc.templatedSinks {
+		if s.Namespace == namespace {
+			delete(sc.templatedSinks, k)
+		}
+	}
+	sc.mu.Unlock()
+	sc.notify(changed)
+}
+
+// allSinks returns the tracked sinks along with the templated sinks that no
+// tracked sink takes precedence over.
+func (sc *Config) allSinks() map[string]*v1alpha1.LogSink {
+	if len(sc.templatedSinks) == 0 {
+		return sc.sinks
+	}
+	all := make(map[string]*v1alpha1.LogSink, len(sc.sinks)+len(sc.templatedSinks))
+	for k, s := range sc.templatedSinks {
+		all[k] = s
+	}
+	for k, s := range sc.sinks {
+		all[k] = s
+	}
+	return all
+}
+
+func expandSinkTemplate(t *template.Template, namespace string) (*v1alpha1.LogSink, error) {
+	var b bytes.Buffer
+	err := t.Execute(&b, struct{ Namespace string }{namespace})
+	if err != nil {
+		return nil, err
+	}
+	var s v1alpha1.LogSink
+	if err := json.Unmarshal(b.Bytes(), &s); err != nil {
+		return nil, err
+	}
+	s.Namespace = namespace
+	return &s, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
 	"net"
@@ -25,12 +26,10 @@ const (
 	ConfigIncludesKubernetesError  = "Kubernetes input plugin added by default in ClusterMetricSink"
 	ConfigLogNoTypeError           = "LogSink should have type"
 	ConfigLogChangeTypeError       = "Changing sink type invalid"
-	ConfigSyslogBadPortError       = "Port for syslog invalid, should be between 1 and 65535"
-	ConfigSyslogBadHostError       = "Host for syslog invalid"
-	ConfigWebhookBadURLError       = "URL for webhook invalid"
 	ConfigMetricNoTypeError        = "Must specify type for each inputs/outputs"
 	ConfigMetricNonStringTypeError = "Input/output type must be a string"
 	ConfigMetricNoInputError       = "MetricSinks require at least one input"
+	ConfigMetricPluginError        = "Input/output is missing required configuration"
 )
 
 type ServerOpt func(*Server)
@@ -168,6 +167,30 @@ func toAdmissionErrorResponse(err string) *v1beta1.AdmissionResponse {
 	}
 }
 
+// toFieldErrorResponse rejects a sink with the message of err. The JSONPath of
+// the invalid field is given as the cause in the details of the status when
+// err is a FieldError.
+func toFieldErrorResponse(err error) *v1beta1.AdmissionResponse {
+	resp := toAdmissionErrorResponse(err.Error())
+	var fieldErr *sink.FieldError
+	if errors.As(err, &fieldErr) {
+		resp.Result.Details = &metav1.StatusDetails{
+			Causes: []metav1.StatusCause{{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: fieldErr.Detail,
+				Field:   "spec." + fieldErr.Field,
+			}},
+		}
+	}
+	return resp
+}
+
+// requiredFieldResponse rejects a sink without the required field, giving
+// the JSONPath of the field like toFieldErrorResponse.
+func requiredFieldResponse(field string) *v1beta1.AdmissionResponse {
+	return toFieldErrorResponse(&sink.FieldError{Field: field, Detail: field + " must be set"})
+}
+
 func logSinkHandler(w http.ResponseWriter, r *http.Request) {
 	requestedAdmissionReview, httpErr := deserializeReview(r)
 	if httpErr != nil {
@@ -205,19 +228,35 @@ func validateLogSinkConfigRequest(rar *v1beta1.AdmissionReview) (*v1beta1.Admiss
 
 	switch cls.Spec.Type {
 	case "syslog":
+	case "webhook":
+		if cls.Spec.URL == "" {
+			return requiredFieldResponse("url"), nil
+		}
+	case "gelf":
 		if cls.Spec.Host == "" {
-			return toAdmissionErrorResponse(ConfigSyslogBadHostError), nil
+			return requiredFieldResponse("host"), nil
 		}
-		if cls.Spec.Port > 65535 || cls.Spec.Port < 1 {
-			return toAdmissionErrorResponse(ConfigSyslogBadPortError), nil
+	case "otlp":
+		if cls.Spec.Host == "" {
+			return requiredFieldResponse("host"), nil
 		}
-	case "webhook":
-		if cls.Spec.URL == "" {
-			return toAdmissionErrorResponse(ConfigWebhookBadURLError), nil
+	case "datadog":
+		if cls.Spec.APIKey == "" {
+			return requiredFieldResponse("api_key"), nil
+		}
+	case "azure":
+		if cls.Spec.CustomerID == "" {
+			return requiredFieldResponse("customer_id"), nil
+		}
+		if cls.Spec.SharedKey == "" {
+			return requiredFieldResponse("shared_key"), nil
 		}
 	default:
 		return toAdmissionErrorResponse(ConfigLogNoTypeError), nil
 	}
+	if err := cls.Spec.Validate(); err != nil {
+		return toFieldErrorResponse(err), nil
+	}
 	return &v1beta1.AdmissionResponse{
 		UID:     rar.Request.UID,
 		Allowed: true,
@@ -255,6 +294,10 @@ func validateMetricSinkConfig(rar v1beta1.AdmissionReview, cms sink.ClusterMetri
 		return toAdmissionErrorResponse(ConfigMetricNoInputError), nil
 	}
 
+	if err := metric.ValidateSpec(cms.Spec); err != nil {
+		return toAdmissionErrorResponse(ConfigMetricPluginError), nil
+	}
+
 	// Which version of default inputs irrelevant to validation at time of
 	// commit.
 	cfg := metric.NewConfig("", metric.KubernetesDefault(false))
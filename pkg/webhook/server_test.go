@@ -187,6 +187,42 @@ func TestValidator(t *testing.T) {
 						"url": "https://example.com/place"
 					}`,
 				},
+				{
+					"gelf",
+					`{
+						"type": "gelf",
+						"host": "example.com",
+						"port": 12201,
+						"mode": "tcp"
+					}`,
+				},
+				{
+					"otlp",
+					`{
+						"type": "otlp",
+						"host": "otel.example.com",
+						"port": 4318,
+						"uri": "/v1/logs",
+						"headers": {"Authorization": "Bearer token"}
+					}`,
+				},
+				{
+					"datadog",
+					`{
+						"type": "datadog",
+						"api_key": "some-key",
+						"service": "some-service"
+					}`,
+				},
+				{
+					"azure",
+					`{
+						"type": "azure",
+						"customer_id": "some-workspace",
+						"shared_key": "some-key",
+						"log_type": "some-type"
+					}`,
+				},
 			}
 			server := webhook.NewServer("127.0.0.1:0")
 			server.Run(false)
@@ -245,6 +281,122 @@ func TestValidator(t *testing.T) {
 					}`,
 					"LogSink should have type",
 				},
+			}
+			server := webhook.NewServer("127.0.0.1:0")
+			server.Run(false)
+			defer server.Close()
+
+			for _, test := range tests {
+				for ttype, template := range map[string]string{
+					"cluster":   clusterLogSinkAdmissionTemplate,
+					"namespace": logSinkAdmissionTemplate,
+				} {
+					t.Run(test.name+"/"+ttype, func(t *testing.T) {
+						var (
+							err  error
+							resp *http.Response
+						)
+						for i := 0; i < 100; i++ {
+							resp, err = http.Post(
+								"http://"+server.Addr()+"/logsink",
+								"application/json",
+								strings.NewReader(fmt.Sprintf(template, test.specObject)),
+							)
+							if err == nil {
+								break
+							}
+							time.Sleep(5 * time.Millisecond)
+						}
+						if err != nil {
+							t.Error(err)
+						}
+						if resp.StatusCode != http.StatusOK {
+							t.Errorf("expected http status 200, got %d", resp.StatusCode)
+						}
+						defer resp.Body.Close()
+
+						var actualResp v1beta1.AdmissionReview
+						err = json.NewDecoder(resp.Body).Decode(&actualResp)
+						if err != nil {
+							t.Errorf("unable to decode resp body: %s", err)
+						}
+
+						expectedInvalidResponse := v1beta1.AdmissionReview{
+							Response: &v1beta1.AdmissionResponse{
+								Result: &metav1.Status{
+									Message: test.errorResponse,
+								},
+							},
+						}
+						if diff := cmp.Diff(expectedInvalidResponse, actualResp); diff != "" {
+							t.Errorf("As (-want, +got) = %v", diff)
+						}
+					})
+				}
+			}
+		})
+		t.Run("returns the path of an invalid field", func(t *testing.T) {
+			tests := []struct {
+				name       string
+				specObject string
+				message    string
+				field      string
+			}{
+				{
+					"relative url",
+					`{
+						"type": "webhook",
+						"url": "/relative/path"
+					}`,
+					"url must be an absolute URL with a host",
+					"spec.url",
+				},
+				{
+					"ca file with ca secret ref",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 12345,
+						"enable_tls": true,
+						"ca_file": "/etc/ssl/ca.pem",
+						"ca_secret_ref": {"name": "some-secret", "key": "ca.crt"}
+					}`,
+					"ca_file and ca_secret_ref are mutually exclusive",
+					"spec.ca_file",
+				},
+				{
+					"cert file without key file",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 12345,
+						"enable_tls": true,
+						"cert_file": "/etc/ssl/client.pem"
+					}`,
+					"cert_file and key_file must be set together",
+					"spec.key_file",
+				},
+				{
+					"negative max record bytes",
+					`{
+						"type": "webhook",
+						"url": "https://example.com",
+						"max_record_bytes": -1
+					}`,
+					"max_record_bytes must not be negative",
+					"spec.max_record_bytes",
+				},
+				{
+					"unknown syslog transport",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 12345,
+						"transport": "sctp"
+					}`,
+					"transport must be one of [tcp udp]",
+					"spec.transport",
+				},
 				{
 					"high port",
 					`{
@@ -252,7 +404,8 @@ func TestValidator(t *testing.T) {
 						"host": "example.com",
 						"port": 100000
 					}`,
-					"Port for syslog invalid, should be between 1 and 65535",
+					"port must be between 1 and 65535",
+					"spec.port",
 				},
 				{
 					"low port",
@@ -261,7 +414,8 @@ func TestValidator(t *testing.T) {
 						"host": "example.com",
 						"port": 0
 					}`,
-					"Port for syslog invalid, should be between 1 and 65535",
+					"port must be between 1 and 65535",
+					"spec.port",
 				},
 				{
 					"no port",
@@ -269,7 +423,8 @@ func TestValidator(t *testing.T) {
 						"type": "syslog",
 						"host": "example.com"
 					}`,
-					"Port for syslog invalid, should be between 1 and 65535",
+					"port must be between 1 and 65535",
+					"spec.port",
 				},
 				{
 					"no host",
@@ -277,14 +432,280 @@ func TestValidator(t *testing.T) {
 						"type": "syslog",
 						"port": 0
 					}`,
-					"Host for syslog invalid",
+					"host must not be empty",
+					"spec.host",
+				},
+				{
+					"insecure skip verify without tls",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 12345,
+						"insecure_skip_verify": true
+					}`,
+					"insecure_skip_verify requires enable_tls",
+					"spec.insecure_skip_verify",
+				},
+				{
+					"too many workers",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"workers": 17
+					}`,
+					"workers must be between 0 and 16",
+					"spec.workers",
+				},
+				{
+					"negative workers",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 12345,
+						"workers": -1
+					}`,
+					"workers must be between 0 and 16",
+					"spec.workers",
+				},
+				{
+					"negative sample rate",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"sample_rate": -1
+					}`,
+					"sample_rate must not be negative",
+					"spec.sample_rate",
+				},
+				{
+					"bad backpressure",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"on_backpressure": "retry"
+					}`,
+					"on_backpressure must be drop or block",
+					"spec.on_backpressure",
+				},
+				{
+					"bad date format",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"date_format": "rfc3339"
+					}`,
+					"date_format must be one of [iso8601 epoch java_sql_timestamp]",
+					"spec.date_format",
+				},
+				{
+					"bad route by field",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 12345,
+						"route_by_field": "kubernetes.namespace_name"
+					}`,
+					"route_by_field must be a dot separated field path on a webhook sink",
+					"spec.route_by_field",
+				},
+				{
+					"bad min severity",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"min_severity": "warn"
+					}`,
+					"severity must be one of [emerg alert crit err warning notice info debug]",
+					"spec.min_severity",
+				},
+				{
+					"bad redact",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"redact": ["pass(word"]
+					}`,
+					"redact must be a list of regular expressions",
+					"spec.redact",
+				},
+				{
+					"bad chunk size",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"chunk_size": "512 kilobytes"
+					}`,
+					"chunk_size and buffer_size must be a size such as 512K or 64M",
+					"spec.chunk_size",
+				},
+				{
+					"bad matches",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"matches": ["*_ns_a-*", ""]
+					}`,
+					"matches must be a list of patterns without whitespace",
+					"spec.matches",
+				},
+				{
+					"route without url",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"routes": [{"field": "level", "pattern": "^error$"}]
+					}`,
+					"routes must each have a field path, a pattern and a url, on a webhook sink without route_by_field",
+					"spec.routes",
+				},
+				{
+					"bad parser",
+					`{
+						"type": "webhook",
+						"url": "https://example.com/place",
+						"parser": "some parser"
+					}`,
+					"parser must be a name without whitespace",
+					"spec.parser",
+				},
+				{
+					"syslog bad transport",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 514,
+						"transport": "udp",
+						"enable_tls": true
+					}`,
+					"enable_tls is not supported with transport udp",
+					"spec.transport",
+				},
+				{
+					"syslog bad format",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 514,
+						"syslog_format": "rfc822"
+					}`,
+					"syslog_format must be one of [rfc5424 rfc3164]",
+					"spec.syslog_format",
+				},
+				{
+					"relative tail source path",
+					`{
+						"type": "webhook",
+						"url": "https://example.com",
+						"tail_source": {
+							"path": "app.log",
+							"tag": "app"
+						}
+					}`,
+					"tail_source must have an absolute path and a tag without whitespace",
+					"spec.tail_source",
+				},
+				{
+					"syslog with url",
+					`{
+						"type": "syslog",
+						"host": "example.com",
+						"port": 514,
+						"url": "https://example.com"
+					}`,
+					"url is not supported by syslog sinks",
+					"spec.url",
+				},
+				{
+					"webhook with port",
+					`{
+						"type": "webhook",
+						"url": "https://example.com",
+						"port": 443
+					}`,
+					"port is not supported by webhook sinks",
+					"spec.port",
+				},
+				{
+					"gelf no host",
+					`{
+						"type": "gelf",
+						"port": 12201
+					}`,
+					"host must be set",
+					"spec.host",
+				},
+				{
+					"gelf no port",
+					`{
+						"type": "gelf",
+						"host": "example.com"
+					}`,
+					"port must be between 1 and 65535",
+					"spec.port",
+				},
+				{
+					"gelf bad mode",
+					`{
+						"type": "gelf",
+						"host": "example.com",
+						"port": 12201,
+						"mode": "http"
+					}`,
+					"mode must be one of [udp tcp tls]",
+					"spec.mode",
+				},
+				{
+					"otlp no host",
+					`{
+						"type": "otlp",
+						"port": 4318
+					}`,
+					"host must be set",
+					"spec.host",
+				},
+				{
+					"otlp no port",
+					`{
+						"type": "otlp",
+						"host": "otel.example.com"
+					}`,
+					"port must be between 1 and 65535",
+					"spec.port",
+				},
+				{
+					"datadog no api key",
+					`{
+						"type": "datadog",
+						"site": "datadoghq.eu"
+					}`,
+					"api_key must be set",
+					"spec.api_key",
+				},
+				{
+					"azure no customer id",
+					`{
+						"type": "azure",
+						"shared_key": "some-key"
+					}`,
+					"customer_id must be set",
+					"spec.customer_id",
+				},
+				{
+					"azure no shared key",
+					`{
+						"type": "azure",
+						"customer_id": "some-workspace"
+					}`,
+					"shared_key must be set",
+					"spec.shared_key",
 				},
 				{
 					"no url",
 					`{
 						"type": "webhook"
 					}`,
-					"URL for webhook invalid",
+					"url must be set",
+					"spec.url",
 				},
 				{
 					"mismatch properties",
@@ -293,7 +714,8 @@ func TestValidator(t *testing.T) {
 						"host": "example.com",
 						"port": 5678
 					}`,
-					"URL for webhook invalid",
+					"url must be set",
+					"spec.url",
 				},
 			}
 			server := webhook.NewServer("127.0.0.1:0")
@@ -322,10 +744,7 @@ func TestValidator(t *testing.T) {
 							time.Sleep(5 * time.Millisecond)
 						}
 						if err != nil {
-							t.Error(err)
-						}
-						if resp.StatusCode != http.StatusOK {
-							t.Errorf("expected http status 200, got %d", resp.StatusCode)
+							t.Fatal(err)
 						}
 						defer resp.Body.Close()
 
@@ -338,7 +757,14 @@ func TestValidator(t *testing.T) {
 						expectedInvalidResponse := v1beta1.AdmissionReview{
 							Response: &v1beta1.AdmissionResponse{
 								Result: &metav1.Status{
-									Message: test.errorResponse,
+									Message: test.message,
+									Details: &metav1.StatusDetails{
+										Causes: []metav1.StatusCause{{
+											Type:    metav1.CauseTypeFieldValueInvalid,
+											Message: test.message,
+											Field:   test.field,
+										}},
+									},
 								},
 							},
 						}
@@ -536,6 +962,20 @@ func TestValidator(t *testing.T) {
 					}`,
 						webhook.ConfigTelegrafError,
 					},
+					{
+						"prometheus remote write output without host",
+						`{
+						"inputs": [ {
+							"type": "cpu"
+						} ],
+						"outputs": [ {
+							"type": "prometheus_remote_write",
+							"port": 9090,
+							"uri": "/api/v1/write"
+						} ]
+					}`,
+						webhook.ConfigMetricPluginError,
+					},
 				}
 				if ttype == "Namespace" {
 					tests = append(tests, invalidValidationTest{